@@ -1,18 +1,34 @@
 package aria2go
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type Aria2Client struct {
 	Token string
 	Addr  string
 	Port  string
+
+	// Timeout 单次请求超时时间,为 0 时不限制
+	// ctx 中设置的 deadline 早于 Timeout 时以 ctx 为准
+	Timeout time.Duration
+
+	useWebsocket bool
+	secure       bool
+	rpcPath      string
+	tlsConfig    *tls.Config
+	httpClient   *http.Client
+	header       http.Header
+	transport    Transport
+	ws           *websocketConn
 }
 
 type Aria2ClientOption func(*Aria2Client)
@@ -29,330 +45,691 @@ func ClientSetPort(port string) Aria2ClientOption {
 	}
 }
 
+// ClientSetTimeout 设置单次请求超时时间
+func ClientSetTimeout(timeout time.Duration) Aria2ClientOption {
+	return func(client *Aria2Client) {
+		client.Timeout = timeout
+	}
+}
+
+// ClientUseWebsocket 使用 websocket 与 aria2 通信
+// 所有请求复用同一个连接,连接断开后会在下一次请求时自动重连
+func ClientUseWebsocket() Aria2ClientOption {
+	return func(client *Aria2Client) {
+		client.useWebsocket = true
+	}
+}
+
+// ClientUseTLS 使用 https/wss 与 aria2 通信
+// 对应 aria2 的 --rpc-secure 参数,config 为 nil 时使用默认配置
+// 在 ClientSetRootCAs ClientSetCertificate 之后使用时会合并配置:
+// config 没有设置 RootCAs 时保留已设置的 CA,客户端证书追加到 config 的证书之后
+func ClientUseTLS(config *tls.Config) Aria2ClientOption {
+	return func(client *Aria2Client) {
+		client.secure = true
+		if config == nil {
+			return
+		}
+		merged := config.Clone()
+		if current := client.tlsConfig; current != nil {
+			if merged.RootCAs == nil {
+				merged.RootCAs = current.RootCAs
+			}
+			merged.Certificates = append(merged.Certificates, current.Certificates...)
+		}
+		client.tlsConfig = merged
+	}
+}
+
+// ClientSetRootCAs 设置校验 aria2 证书使用的 CA,用于自签名证书
+// 设置后自动使用 https/wss
+func ClientSetRootCAs(pool *x509.CertPool) Aria2ClientOption {
+	return func(client *Aria2Client) {
+		client.secure = true
+		client.getTLSConfig().RootCAs = pool
+	}
+}
+
+// ClientSetCertificate 设置客户端证书
+// 设置后自动使用 https/wss
+func ClientSetCertificate(cert tls.Certificate) Aria2ClientOption {
+	return func(client *Aria2Client) {
+		client.secure = true
+		config := client.getTLSConfig()
+		config.Certificates = append(config.Certificates, cert)
+	}
+}
+
+// ClientSetHTTPClient 使用自定义的 http.Client 发送请求,可以用于设置代理和连接池
+// 设置后 ClientUseTLS ClientSetRootCAs ClientSetCertificate 的 tls 配置不会作用于 http 请求
+func ClientSetHTTPClient(httpClient *http.Client) Aria2ClientOption {
+	return func(client *Aria2Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// ClientSetHeader 添加请求头,可以多次调用添加多个
+func ClientSetHeader(key, value string) Aria2ClientOption {
+	return func(client *Aria2Client) {
+		if client.header == nil {
+			client.header = make(http.Header)
+		}
+		client.header.Add(key, value)
+	}
+}
+
+// ClientSetRPCPath 设置 rpc 路径,默认为 /jsonrpc
+// 用于 aria2 部署在反向代理之后的情况
+func ClientSetRPCPath(path string) Aria2ClientOption {
+	return func(client *Aria2Client) {
+		client.rpcPath = "/" + strings.TrimPrefix(path, "/")
+	}
+}
+
+// ClientSetTransport 使用自定义传输层
+// 设置后 Addr Port 和其他连接相关的设置都不再生效,订阅通知也不可用
+func ClientSetTransport(transport Transport) Aria2ClientOption {
+	return func(client *Aria2Client) {
+		client.transport = transport
+	}
+}
+
 func NewAria2Client(token string, opt ...Aria2ClientOption) *Aria2Client {
 	token = strings.TrimSpace(token)
-	client := &Aria2Client{Token: token, Addr: DEFAULT_ARIA2_ADDR, Port: DEFAULT_ARIA2_PORT}
+	client := &Aria2Client{Token: token, Addr: DEFAULT_ARIA2_ADDR, Port: DEFAULT_ARIA2_PORT, rpcPath: DEFAULT_RPC_PATH}
 
 	for _, obj := range opt {
 		obj(client)
 	}
 
+	if client.transport == nil {
+		if client.useWebsocket {
+			client.ws = newWebsocketConn(client.serverURL("ws"), client.tlsConfig, client.header)
+			client.transport = client.ws
+		} else {
+			httpClient, owned := client.newHTTPClient()
+			client.transport = &httpTransport{url: client.serverURL("http"), client: httpClient, header: client.header.Clone(), owned: owned}
+		}
+	}
+
 	return client
 }
 
-// SendRequest 发送请求
-func (a Aria2Client) SendRequest(body []byte) (result []byte, err error) {
-	serverAddr := fmt.Sprintf("http://%s:%s/jsonrpc", a.Addr, a.Port)
-	request, err := http.NewRequest("POST", serverAddr, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, err
+// getTLSConfig 获取 tls 配置,不存在时创建
+func (a *Aria2Client) getTLSConfig() *tls.Config {
+	if a.tlsConfig == nil {
+		a.tlsConfig = &tls.Config{}
 	}
-	request.Header.Set("ContentType", DEFAULT_CONTENT_TYPE)
-	request.Header.Set("Accept-Charset", "utf-8")
+	return a.tlsConfig
+}
 
-	client := &http.Client{}
+// newHTTPClient 根据配置创建 http.Client
+// owned 表示 client 的连接池由本库创建,没有 tls 配置时使用共享的 http.DefaultTransport
+func (a *Aria2Client) newHTTPClient() (httpClient *http.Client, owned bool) {
+	if a.httpClient != nil {
+		return a.httpClient, false
+	}
+	if a.tlsConfig == nil {
+		return &http.Client{}, false
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = a.tlsConfig
+	return &http.Client{Transport: transport}, true
+}
 
-	response, err := client.Do(request)
-	if err != nil {
-		return nil, err
+// serverURL 生成 aria2 rpc 地址,scheme 为 http 或 ws
+func (a Aria2Client) serverURL(scheme string) string {
+	if a.secure {
+		scheme += "s"
 	}
-	resultJsonData, err := io.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
+	path := a.rpcPath
+	if path == "" {
+		path = DEFAULT_RPC_PATH
 	}
+	return fmt.Sprintf("%s://%s:%s%s", scheme, a.Addr, a.Port, path)
+}
 
-	result = resultJsonData
-	return
+// Close 关闭传输层持有的连接
+func (a Aria2Client) Close() error {
+	if a.transport != nil {
+		return a.transport.Close()
+	}
+	return nil
 }
 
-func (a Aria2Client) Download(uri string) (gid string, err error) {
-	downloadRequest, _, err := NewRequestWithToken(a.Token).AddUri([]string{uri}, nil).Create()
-	if err != nil {
-		return "", err
+// SendRequest 发送请求
+func (a Aria2Client) SendRequest(body []byte) (result []byte, err error) {
+	return a.SendRequestContext(context.Background(), body)
+}
+
+// SendRequestContext 发送请求,通过 ctx 控制请求的取消和超时
+// 请求被取消或超时时返回的错误可以使用 errors.Is 判断 context.Canceled 和 context.DeadlineExceeded
+// 通信失败时返回 *TransportError
+func (a Aria2Client) SendRequestContext(ctx context.Context, body []byte) (result []byte, err error) {
+	if a.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.Timeout)
+		defer cancel()
 	}
 
-	requestResult, err := a.SendRequest(downloadRequest)
-	if err != nil {
-		return "", err
+	transport := a.transport
+	if transport == nil {
+		transport = NewHTTPTransport(a.serverURL("http"), nil, nil)
 	}
 
-	resp := &Response{}
-	err = json.Unmarshal(requestResult, &resp)
+	result, err = transport.SendRequest(ctx, body)
 	if err != nil {
-		return "", err
+		return nil, wrapTransportError(ctx, err)
 	}
+	return result, nil
+}
 
-	if resp.Error != nil {
-		return "", fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
+// wrapTransportError 请求因 ctx 结束而失败时,返回说明取消或超时的错误
+// 其他错误包装为 *TransportError
+func wrapTransportError(ctx context.Context, err error) error {
+	switch ctx.Err() {
+	case context.Canceled:
+		return fmt.Errorf("aria2 request canceled: %w", context.Canceled)
+	case context.DeadlineExceeded:
+		return fmt.Errorf("aria2 request timed out: %w", context.DeadlineExceeded)
+	}
+	if errors.Is(err, ErrTransport) {
+		return err
 	}
-	return resp.Result, nil
+	return &TransportError{Err: err}
+}
+
+func (a Aria2Client) Download(uri string) (gid string, err error) {
+	return a.DownloadContext(context.Background(), uri)
+}
+
+// DownloadContext 同 Download,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) DownloadContext(ctx context.Context, uri string) (gid string, err error) {
+	gid, _, err = CallContext[string](ctx, &a, NewRequestWithToken(a.Token).AddUri([]string{uri}, nil))
+	return
+}
+
+// DownloadUris 使用多个下载源创建下载任务,所有下载源需要指向同一个文件
+// position 为新任务在等待队列中的位置,参考 RequestBody.AddUri
+func (a Aria2Client) DownloadUris(uris []string, option *Option, position ...int) (gid string, err error) {
+	return a.DownloadUrisContext(context.Background(), uris, option, position...)
+}
+
+// DownloadUrisContext 同 DownloadUris,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) DownloadUrisContext(ctx context.Context, uris []string, option *Option, position ...int) (gid string, err error) {
+	gid, _, err = CallContext[string](ctx, &a, NewRequestWithToken(a.Token).AddUri(uris, option, position...))
+	return
 }
 
 func (a Aria2Client) DownloadWithLocalTorrent(filePath string) (gid string, err error) {
-	downloadRequest, _, err := NewRequestWithToken(a.Token).AddTorrent(filePath, nil).Create()
-	if err != nil {
-		return "", err
-	}
+	return a.DownloadWithLocalTorrentContext(context.Background(), filePath)
+}
 
-	requestResult, err := a.SendRequest(downloadRequest)
-	if err != nil {
-		return "", err
-	}
+// DownloadWithLocalTorrentContext 同 DownloadWithLocalTorrent,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) DownloadWithLocalTorrentContext(ctx context.Context, filePath string) (gid string, err error) {
+	gid, _, err = CallContext[string](ctx, &a, NewRequestWithToken(a.Token).AddTorrent(filePath, nil))
+	return
+}
 
-	resp := &Response{}
-	err = json.Unmarshal(requestResult, &resp)
-	if err != nil {
-		return "", err
-	}
+// DownloadWithTorrent 从 reader 读取 bt 文件内容创建下载任务
+// uris 为 web-seed 地址,position 为新任务在等待队列中的位置,参考 RequestBody.AddTorrentData
+func (a Aria2Client) DownloadWithTorrent(reader io.Reader, uris []string, option *Option, position ...int) (gid string, err error) {
+	return a.DownloadWithTorrentContext(context.Background(), reader, uris, option, position...)
+}
 
-	if resp.Error != nil {
-		return "", fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-	}
-	return resp.Result, nil
+// DownloadWithTorrentContext 同 DownloadWithTorrent,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) DownloadWithTorrentContext(ctx context.Context, reader io.Reader, uris []string, option *Option, position ...int) (gid string, err error) {
+	gid, _, err = CallContext[string](ctx, &a, NewRequestWithToken(a.Token).AddTorrentReader(reader, uris, option, position...))
+	return
+}
+
+// DownloadWithTorrentData 使用 bt 文件内容创建下载任务,参数同 DownloadWithTorrent
+func (a Aria2Client) DownloadWithTorrentData(torrent []byte, uris []string, option *Option, position ...int) (gid string, err error) {
+	return a.DownloadWithTorrentDataContext(context.Background(), torrent, uris, option, position...)
+}
+
+// DownloadWithTorrentDataContext 同 DownloadWithTorrentData,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) DownloadWithTorrentDataContext(ctx context.Context, torrent []byte, uris []string, option *Option, position ...int) (gid string, err error) {
+	gid, _, err = CallContext[string](ctx, &a, NewRequestWithToken(a.Token).AddTorrentData(torrent, uris, option, position...))
+	return
+}
+
+// DownloadWithLocalMetalink 添加本地 metalink 文件创建下载任务
+// metalink 中可能包含多个文件,返回创建的所有任务的 gid
+func (a Aria2Client) DownloadWithLocalMetalink(filePath string) (gids []string, err error) {
+	return a.DownloadWithLocalMetalinkContext(context.Background(), filePath)
+}
+
+// DownloadWithLocalMetalinkContext 同 DownloadWithLocalMetalink,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) DownloadWithLocalMetalinkContext(ctx context.Context, filePath string) (gids []string, err error) {
+	gids, _, err = CallContext[[]string](ctx, &a, NewRequestWithToken(a.Token).AddMetalink(filePath, nil))
+	return
+}
+
+// DownloadWithMetalink 从 reader 读取 metalink 文件内容创建下载任务,返回创建的所有任务的 gid
+// position 为新任务在等待队列中的位置,参考 RequestBody.AddMetalinkData
+func (a Aria2Client) DownloadWithMetalink(reader io.Reader, option *Option, position ...int) (gids []string, err error) {
+	return a.DownloadWithMetalinkContext(context.Background(), reader, option, position...)
+}
+
+// DownloadWithMetalinkContext 同 DownloadWithMetalink,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) DownloadWithMetalinkContext(ctx context.Context, reader io.Reader, option *Option, position ...int) (gids []string, err error) {
+	gids, _, err = CallContext[[]string](ctx, &a, NewRequestWithToken(a.Token).AddMetalinkReader(reader, option, position...))
+	return
 }
 
 func (a Aria2Client) QueryTaskStatus(gid string) (status *TaskStatusData, err error) {
-	request, _, err := NewRequestWithToken(a.Token).TellStatus(gid).Create()
-	if err != nil {
-		return nil, err
-	}
-	requestResult, err := a.SendRequest(request)
-	if err != nil {
-		return nil, err
-	}
-	resp := &TellStatusResponse{}
-	if err := json.Unmarshal(requestResult, &resp); err != nil {
-		return nil, err
-	}
+	return a.QueryTaskStatusContext(context.Background(), gid)
+}
 
-	if resp.Error != nil {
-		return nil, fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-	}
-	return resp.Result, nil
+// QueryTaskStatusContext 同 QueryTaskStatus,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) QueryTaskStatusContext(ctx context.Context, gid string) (status *TaskStatusData, err error) {
+	status, _, err = CallContext[*TaskStatusData](ctx, &a, NewRequestWithToken(a.Token).TellStatus(gid))
+	return
 }
 
 func (a Aria2Client) QueryWaitingTask(offset int, limit int) (tasks []*TaskStatusData, err error) {
-	request, _, err := NewRequestWithToken(a.Token).TellWaiting(offset, limit).Create()
-	if err != nil {
-		return nil, err
-	}
-	requestResult, err := a.SendRequest(request)
-	if err != nil {
-		return nil, err
-	}
-	resp := &TellTaskListResponse{}
-	if err := json.Unmarshal(requestResult, &resp); err != nil {
-		return nil, err
-	}
+	return a.QueryWaitingTaskContext(context.Background(), offset, limit)
+}
 
-	if resp.Error != nil {
-		return nil, fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-	}
-	return resp.Result, nil
+// QueryWaitingTaskContext 同 QueryWaitingTask,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) QueryWaitingTaskContext(ctx context.Context, offset int, limit int) (tasks []*TaskStatusData, err error) {
+	tasks, _, err = CallContext[[]*TaskStatusData](ctx, &a, NewRequestWithToken(a.Token).TellWaiting(offset, limit))
+	return
 }
 
 func (a Aria2Client) QueryStoppedTask(offset int, limit int) (tasks []*TaskStatusData, err error) {
-	request, _, err := NewRequestWithToken(a.Token).TellStopped(offset, limit).Create()
-	if err != nil {
-		return nil, err
-	}
-	requestResult, err := a.SendRequest(request)
-	if err != nil {
-		return nil, err
-	}
-	resp := &TellTaskListResponse{}
-	if err := json.Unmarshal(requestResult, &resp); err != nil {
-		return nil, err
-	}
+	return a.QueryStoppedTaskContext(context.Background(), offset, limit)
+}
 
-	if resp.Error != nil {
-		return nil, fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-	}
-	return resp.Result, nil
+// QueryStoppedTaskContext 同 QueryStoppedTask,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) QueryStoppedTaskContext(ctx context.Context, offset int, limit int) (tasks []*TaskStatusData, err error) {
+	tasks, _, err = CallContext[[]*TaskStatusData](ctx, &a, NewRequestWithToken(a.Token).TellStopped(offset, limit))
+	return
 }
 
 func (a Aria2Client) QueryDownloadingTask() (tasks []*TaskStatusData, err error) {
-	request, _, err := NewRequestWithToken(a.Token).TellActive().Create()
-	if err != nil {
-		return nil, err
-	}
-	requestResult, err := a.SendRequest(request)
-	if err != nil {
-		return nil, err
-	}
-	resp := &TellTaskListResponse{}
-	if err := json.Unmarshal(requestResult, &resp); err != nil {
-		return nil, err
-	}
+	return a.QueryDownloadingTaskContext(context.Background())
+}
 
-	if resp.Error != nil {
-		return nil, fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-	}
-	return resp.Result, nil
+// QueryDownloadingTaskContext 同 QueryDownloadingTask,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) QueryDownloadingTaskContext(ctx context.Context) (tasks []*TaskStatusData, err error) {
+	tasks, _, err = CallContext[[]*TaskStatusData](ctx, &a, NewRequestWithToken(a.Token).TellActive())
+	return
 }
 
 func (a Aria2Client) QueryNotDownloadingTask() (tasks []*TaskStatusData, err error) {
-	offset := 0
-	limit := 50
-	count := 1
-	waitingTasks := make([]*TaskStatusData, 0)
-	stoppedTasks := make([]*TaskStatusData, 0)
-
-	for {
-		waitingReq := NewRequestWithToken(a.Token).TellWaiting(offset, limit)
-		stoppedReq := NewRequestWithToken(a.Token).TellStopped(offset, limit)
-		request, _, err := NewRequest().MultiCall(waitingReq, stoppedReq).Create()
-		if err != nil {
-			return nil, err
-		}
-		result, err := a.SendRequest(request)
-		if err != nil {
-			return nil, err
+	return a.QueryNotDownloadingTaskContext(context.Background())
+}
+
+// QueryNotDownloadingTaskContext 同 QueryNotDownloadingTask,通过 ctx 控制请求的取消和超时
+// 任务较多时使用 IterWaiting IterStopped 逐个遍历,避免一次加载所有任务
+func (a Aria2Client) QueryNotDownloadingTaskContext(ctx context.Context) (tasks []*TaskStatusData, err error) {
+	tasks = make([]*TaskStatusData, 0)
+	iterators := []*TaskIterator{
+		a.IterWaitingContext(ctx, 0, DEFAULT_PAGE_SIZE),
+		a.IterStoppedContext(ctx, 0, DEFAULT_PAGE_SIZE),
+	}
+	for _, iter := range iterators {
+		for iter.Next() {
+			tasks = append(tasks, iter.Task())
 		}
-		resp := &QueryNotDownloadingTaskResponse{}
-		err = json.Unmarshal(result, &resp)
-		if err != nil {
+		if err := iter.Err(); err != nil {
 			return nil, err
 		}
-		if resp.Error != nil {
-			return nil, fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-		}
-
-		waitingTaskRes := resp.Result[0][0]
-		stoppedTaskRes := resp.Result[1][0]
-
-		if len(waitingTaskRes) == 0 && len(stoppedTaskRes) == 0 {
-			break
-		}
-		waitingTasks = append(waitingTasks, waitingTaskRes...)
-		stoppedTasks = append(stoppedTasks, stoppedTaskRes...)
-		count += 1
-		offset = count * limit
 	}
-
-	allTasks := make([]*TaskStatusData, 0, len(waitingTasks)+len(stoppedTasks))
-	allTasks = append(allTasks, waitingTasks...)
-	allTasks = append(allTasks, stoppedTasks...)
-
-	return allTasks, nil
+	return tasks, nil
 }
 
 func (a Aria2Client) Pause(gid string) error {
-	request, _, err := NewRequestWithToken(a.Token).Pause(gid, false).Create()
-	if err != nil {
-		return err
-	}
-	requestResult, err := a.SendRequest(request)
-	if err != nil {
-		return err
-	}
-	resp := &Response{}
-	if err := json.Unmarshal(requestResult, &resp); err != nil {
-		return err
-	}
+	return a.PauseContext(context.Background(), gid)
+}
 
-	if resp.Error != nil {
-		return fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-	}
-	return nil
+// PauseContext 同 Pause,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) PauseContext(ctx context.Context, gid string) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).Pause(gid, false))
+	return err
 }
 
 func (a Aria2Client) Unpause(gid string) error {
-	request, _, err := NewRequestWithToken(a.Token).Unpause(gid).Create()
-	if err != nil {
-		return err
-	}
-	requestResult, err := a.SendRequest(request)
-	if err != nil {
-		return err
-	}
-	resp := &Response{}
-	if err := json.Unmarshal(requestResult, &resp); err != nil {
-		return err
-	}
+	return a.UnpauseContext(context.Background(), gid)
+}
 
-	if resp.Error != nil {
-		return fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-	}
-	return nil
+// UnpauseContext 同 Unpause,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) UnpauseContext(ctx context.Context, gid string) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).Unpause(gid))
+	return err
 }
 
 func (a Aria2Client) PauseAll(gid string) error {
-	request, _, err := NewRequestWithToken(a.Token).PauseAll(false).Create()
-	if err != nil {
-		return err
-	}
-	requestResult, err := a.SendRequest(request)
-	if err != nil {
-		return err
-	}
-	resp := &Response{}
-	if err := json.Unmarshal(requestResult, &resp); err != nil {
-		return err
-	}
+	return a.PauseAllContext(context.Background(), gid)
+}
 
-	if resp.Error != nil {
-		return fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-	}
-	return nil
+// PauseAllContext 同 PauseAll,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) PauseAllContext(ctx context.Context, gid string) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).PauseAll(false))
+	return err
 }
 
 func (a Aria2Client) UnpauseAll(gid string) error {
-	request, _, err := NewRequestWithToken(a.Token).UnpauseAll().Create()
-	if err != nil {
-		return err
-	}
-	requestResult, err := a.SendRequest(request)
-	if err != nil {
-		return err
-	}
-	resp := &Response{}
-	if err := json.Unmarshal(requestResult, &resp); err != nil {
-		return err
-	}
+	return a.UnpauseAllContext(context.Background(), gid)
+}
 
-	if resp.Error != nil {
-		return fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-	}
-	return nil
+// UnpauseAllContext 同 UnpauseAll,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) UnpauseAllContext(ctx context.Context, gid string) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).UnpauseAll())
+	return err
 }
 
 func (a Aria2Client) RemoveTask(gid string) error {
-	request, _, err := NewRequestWithToken(a.Token).RemoveDownloadResult(gid).Create()
-	if err != nil {
-		return err
-	}
-	requestResult, err := a.SendRequest(request)
-	if err != nil {
-		return err
-	}
-	resp := &Response{}
-	if err := json.Unmarshal(requestResult, &resp); err != nil {
-		return err
-	}
+	return a.RemoveTaskContext(context.Background(), gid)
+}
 
-	if resp.Error != nil {
-		return fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
-	}
-	return nil
+// RemoveTaskContext 同 RemoveTask,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) RemoveTaskContext(ctx context.Context, gid string) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).RemoveDownloadResult(gid))
+	return err
 }
 
 func (a Aria2Client) RemoveAllTask() error {
-	request, _, err := NewRequestWithToken(a.Token).PurgeDownloadResult().Create()
-	if err != nil {
-		return err
-	}
-	requestResult, err := a.SendRequest(request)
+	return a.RemoveAllTaskContext(context.Background())
+}
+
+// RemoveAllTaskContext 同 RemoveAllTask,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) RemoveAllTaskContext(ctx context.Context) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).PurgeDownloadResult())
+	return err
+}
+
+// ForcePause 强制暂停任务,不等待联系 BitTorrent trackers 等操作
+func (a Aria2Client) ForcePause(gid string) error {
+	return a.ForcePauseContext(context.Background(), gid)
+}
+
+// ForcePauseContext 同 ForcePause,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ForcePauseContext(ctx context.Context, gid string) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).Pause(gid, true))
+	return err
+}
+
+// ForcePauseAll 强制暂停所有任务
+func (a Aria2Client) ForcePauseAll() error {
+	return a.ForcePauseAllContext(context.Background())
+}
+
+// ForcePauseAllContext 同 ForcePauseAll,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ForcePauseAllContext(ctx context.Context) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).PauseAll(true))
+	return err
+}
+
+// Remove 删除下载任务,正在下载的任务会先停止
+func (a Aria2Client) Remove(gid string) error {
+	return a.RemoveContext(context.Background(), gid)
+}
+
+// RemoveContext 同 Remove,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) RemoveContext(ctx context.Context, gid string) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).Remove(gid, false))
+	return err
+}
+
+// ForceRemove 强制删除下载任务,不等待联系 BitTorrent trackers 等操作
+func (a Aria2Client) ForceRemove(gid string) error {
+	return a.ForceRemoveContext(context.Background(), gid)
+}
+
+// ForceRemoveContext 同 ForceRemove,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ForceRemoveContext(ctx context.Context, gid string) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).Remove(gid, true))
+	return err
+}
+
+// ChangePosition 修改任务在等待队列中的位置,返回修改后的位置
+func (a Aria2Client) ChangePosition(gid string, pos int, opt PositionOpt) (position int, err error) {
+	return a.ChangePositionContext(context.Background(), gid, pos, opt)
+}
+
+// ChangePositionContext 同 ChangePosition,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ChangePositionContext(ctx context.Context, gid string, pos int, opt PositionOpt) (position int, err error) {
+	position, _, err = CallContext[int](ctx, &a, NewRequestWithToken(a.Token).ChangePosition(gid, pos, opt))
+	return
+}
+
+// ChangeUri 修改任务文件的下载源,fileIndex 从 1 开始
+// 返回删除和添加的下载源数量
+func (a Aria2Client) ChangeUri(gid string, fileIndex int, delUris, addUris []string) (deleted int, added int, err error) {
+	return a.ChangeUriContext(context.Background(), gid, fileIndex, delUris, addUris)
+}
+
+// ChangeUriContext 同 ChangeUri,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ChangeUriContext(ctx context.Context, gid string, fileIndex int, delUris, addUris []string) (deleted int, added int, err error) {
+	result, _, err := CallContext[[]int](ctx, &a, NewRequestWithToken(a.Token).ChangeUri(gid, fileIndex, delUris, addUris))
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
-	resp := &Response{}
-	if err := json.Unmarshal(requestResult, &resp); err != nil {
-		return err
+	if len(result) != 2 {
+		return 0, 0, &DecodeError{Err: fmt.Errorf("changeUri result length %d", len(result))}
 	}
+	return result[0], result[1], nil
+}
+
+// GetOption 获取任务配置参数
+func (a Aria2Client) GetOption(gid string) (options OptionMap, err error) {
+	return a.GetOptionContext(context.Background(), gid)
+}
+
+// GetOptionContext 同 GetOption,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) GetOptionContext(ctx context.Context, gid string) (options OptionMap, err error) {
+	options, _, err = CallContext[OptionMap](ctx, &a, NewRequestWithToken(a.Token).GetOption(gid))
+	return
+}
 
-	if resp.Error != nil {
-		return fmt.Errorf("code: %d message: %s", resp.Error.Code, resp.Error.Message)
+// ChangeOption 修改任务配置参数,可以修改的参数参考 RequestBody.ChangeOption
+// otherOpt 用于设置 Option 中没有的参数
+func (a Aria2Client) ChangeOption(gid string, opts *Option, otherOpt ...map[string]string) error {
+	return a.ChangeOptionContext(context.Background(), gid, opts, otherOpt...)
+}
+
+// ChangeOptionContext 同 ChangeOption,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ChangeOptionContext(ctx context.Context, gid string, opts *Option, otherOpt ...map[string]string) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).ChangeOption(gid, opts, otherOpt...))
+	return err
+}
+
+// GetGlobalOption 获取全局配置参数
+func (a Aria2Client) GetGlobalOption() (options OptionMap, err error) {
+	return a.GetGlobalOptionContext(context.Background())
+}
+
+// GetGlobalOptionContext 同 GetGlobalOption,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) GetGlobalOptionContext(ctx context.Context) (options OptionMap, err error) {
+	options, _, err = CallContext[OptionMap](ctx, &a, NewRequestWithToken(a.Token).GetGlobalOption())
+	return
+}
+
+// ChangeGlobalOption 修改全局配置参数,可以修改的参数参考 RequestBody.ChangeGlobalOption
+func (a Aria2Client) ChangeGlobalOption(opts *Option, otherOpt ...map[string]string) error {
+	return a.ChangeGlobalOptionContext(context.Background(), opts, otherOpt...)
+}
+
+// ChangeGlobalOptionContext 同 ChangeGlobalOption,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ChangeGlobalOptionContext(ctx context.Context, opts *Option, otherOpt ...map[string]string) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).ChangeGlobalOption(opts, otherOpt...))
+	return err
+}
+
+// GetGlobalStat 获取全局下载状态
+func (a Aria2Client) GetGlobalStat() (stat *GlobalStatData, err error) {
+	return a.GetGlobalStatContext(context.Background())
+}
+
+// GetGlobalStatContext 同 GetGlobalStat,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) GetGlobalStatContext(ctx context.Context) (stat *GlobalStatData, err error) {
+	stat, _, err = CallContext[*GlobalStatData](ctx, &a, NewRequestWithToken(a.Token).GetGlobalStat())
+	return
+}
+
+// GetPeers 获取 BitTorrent 任务的 peer 列表
+func (a Aria2Client) GetPeers(gid string) (peers []*PeerInfo, err error) {
+	return a.GetPeersContext(context.Background(), gid)
+}
+
+// GetPeersContext 同 GetPeers,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) GetPeersContext(ctx context.Context, gid string) (peers []*PeerInfo, err error) {
+	peers, _, err = CallContext[[]*PeerInfo](ctx, &a, NewRequestWithToken(a.Token).GetPeers(gid))
+	return
+}
+
+// GetServers 获取 HTTP(S)/FTP/SFTP 任务正在连接的服务器
+func (a Aria2Client) GetServers(gid string) (servers []*ServerInfo, err error) {
+	return a.GetServersContext(context.Background(), gid)
+}
+
+// GetServersContext 同 GetServers,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) GetServersContext(ctx context.Context, gid string) (servers []*ServerInfo, err error) {
+	servers, _, err = CallContext[[]*ServerInfo](ctx, &a, NewRequestWithToken(a.Token).GetServers(gid))
+	return
+}
+
+// GetUris 获取任务的文件下载源
+func (a Aria2Client) GetUris(gid string) (uris []*TaskStatusDataFileUris, err error) {
+	return a.GetUrisContext(context.Background(), gid)
+}
+
+// GetUrisContext 同 GetUris,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) GetUrisContext(ctx context.Context, gid string) (uris []*TaskStatusDataFileUris, err error) {
+	uris, _, err = CallContext[[]*TaskStatusDataFileUris](ctx, &a, NewRequestWithToken(a.Token).GetUris(gid))
+	return
+}
+
+// GetFiles 获取任务的文件列表
+func (a Aria2Client) GetFiles(gid string) (files []*TaskStatusDataFile, err error) {
+	return a.GetFilesContext(context.Background(), gid)
+}
+
+// GetFilesContext 同 GetFiles,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) GetFilesContext(ctx context.Context, gid string) (files []*TaskStatusDataFile, err error) {
+	files, _, err = CallContext[[]*TaskStatusDataFile](ctx, &a, NewRequestWithToken(a.Token).GetFiles(gid))
+	return
+}
+
+// GetVersion 获取 aria2 的版本和已启用功能
+func (a Aria2Client) GetVersion() (version *GetVersionResponse, err error) {
+	return a.GetVersionContext(context.Background())
+}
+
+// GetVersionContext 同 GetVersion,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) GetVersionContext(ctx context.Context) (version *GetVersionResponse, err error) {
+	version, _, err = CallContext[*GetVersionResponse](ctx, &a, NewRequestWithToken(a.Token).GetVersion())
+	return
+}
+
+// GetSessionInfo 获取会话信息
+func (a Aria2Client) GetSessionInfo() (session *SessionInfo, err error) {
+	return a.GetSessionInfoContext(context.Background())
+}
+
+// GetSessionInfoContext 同 GetSessionInfo,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) GetSessionInfoContext(ctx context.Context) (session *SessionInfo, err error) {
+	session, _, err = CallContext[*SessionInfo](ctx, &a, NewRequestWithToken(a.Token).GetSessionInfo())
+	return
+}
+
+// SaveSession 保存当前会话到 --save-session 指定的文件
+func (a Aria2Client) SaveSession() error {
+	return a.SaveSessionContext(context.Background())
+}
+
+// SaveSessionContext 同 SaveSession,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) SaveSessionContext(ctx context.Context) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).SaveSession())
+	return err
+}
+
+// Shutdown 关闭 aria2
+func (a Aria2Client) Shutdown() error {
+	return a.ShutdownContext(context.Background())
+}
+
+// ShutdownContext 同 Shutdown,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ShutdownContext(ctx context.Context) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).Shutdown(false))
+	return err
+}
+
+// ForceShutdown 强制关闭 aria2,不等待联系 BitTorrent trackers 等操作
+func (a Aria2Client) ForceShutdown() error {
+	return a.ForceShutdownContext(context.Background())
+}
+
+// ForceShutdownContext 同 ForceShutdown,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ForceShutdownContext(ctx context.Context) error {
+	_, _, err := CallContext[string](ctx, &a, NewRequestWithToken(a.Token).Shutdown(true))
+	return err
+}
+
+// ListMethods 获取 aria2 支持的所有方法
+func (a Aria2Client) ListMethods() (methods []string, err error) {
+	return a.ListMethodsContext(context.Background())
+}
+
+// ListMethodsContext 同 ListMethods,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ListMethodsContext(ctx context.Context) (methods []string, err error) {
+	methods, _, err = CallContext[[]string](ctx, &a, NewRequest().ListMethods())
+	return
+}
+
+// ListNotifications 获取 aria2 支持的所有通知
+func (a Aria2Client) ListNotifications() (notifications []string, err error) {
+	return a.ListNotificationsContext(context.Background())
+}
+
+// ListNotificationsContext 同 ListNotifications,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ListNotificationsContext(ctx context.Context) (notifications []string, err error) {
+	notifications, _, err = CallContext[[]string](ctx, &a, NewRequest().ListNotifications())
+	return
+}
+
+// ResolveFollowedBy 沿 followedBy 查找 gid 最终生成的任务
+// magnet 或 .torrent 链接的任务下载完成后会生成真正的 BitTorrent 任务,返回这些任务的 gid
+// 没有生成其他任务时返回 gid 本身
+// 生成的任务已经被 purgeDownloadResult removeDownloadResult 删除时跳过,全部被删除时返回生成它们的任务
+func (a Aria2Client) ResolveFollowedBy(gid string) (gids []string, err error) {
+	return a.ResolveFollowedByContext(context.Background(), gid)
+}
+
+// ResolveFollowedByContext 同 ResolveFollowedBy,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ResolveFollowedByContext(ctx context.Context, gid string) (gids []string, err error) {
+	visited := make(map[string]bool)
+	var resolve func(current string, root bool) ([]string, error)
+	resolve = func(current string, root bool) ([]string, error) {
+		if visited[current] {
+			return nil, nil
+		}
+		visited[current] = true
+
+		status, _, err := CallContext[*TaskStatusData](ctx, &a,
+			NewRequestWithToken(a.Token).TellStatus(current, "gid", "followedBy"))
+		if err != nil {
+			if !root && errors.Is(err, ErrGIDNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		leaves := make([]string, 0)
+		for _, next := range status.FollowedBy {
+			items, err := resolve(next, false)
+			if err != nil {
+				return nil, err
+			}
+			leaves = append(leaves, items...)
+		}
+		if len(leaves) == 0 {
+			leaves = append(leaves, current)
+		}
+		return leaves, nil
 	}
-	return nil
+	return resolve(gid, true)
 }
@@ -1,9 +1,20 @@
 package aria2go
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 var client *Aria2Client
@@ -13,6 +24,19 @@ func TestMain(m *testing.M) {
 	m.Run()
 }
 
+// newTestClient 启动处理 handler 的 httptest 服务,返回连接到该服务的 Aria2Client
+// 测试结束时关闭 client 和服务
+func newTestClient(t *testing.T, handler http.Handler, opt ...Aria2ClientOption) *Aria2Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	addr := strings.Split(strings.TrimPrefix(server.URL, "http://"), ":")
+	testClient := NewAria2Client("thanks", append([]Aria2ClientOption{ClientSetAddr(addr[0]), ClientSetPort(addr[1])}, opt...)...)
+	t.Cleanup(func() { _ = testClient.Close() })
+	return testClient
+}
+
 func TestQueryNotDownloadingTask(t *testing.T) {
 	res, err := client.QueryNotDownloadingTask()
 	if err != nil {
@@ -363,3 +387,410 @@ func TestShutdown(t *testing.T) {
 
 	fmt.Printf("replay id: %s, response: %s\n", id, string(result))
 }
+
+func TestSendRequestContext(t *testing.T) {
+	done := make(chan struct{})
+	timeoutClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}), ClientSetTimeout(50*time.Millisecond))
+	defer close(done)
+
+	_, err := timeoutClient.QueryTaskStatus("2089b05ecca3d829")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline exceeded got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = timeoutClient.QueryTaskStatusContext(ctx, "2089b05ecca3d829")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("want canceled got %v", err)
+	}
+}
+
+func TestTLSTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/aria2/jsonrpc" || r.Header.Get("X-Api-Key") != "secret" ||
+			r.Header.Get("Content-Type") != DEFAULT_CONTENT_TYPE {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"1","jsonrpc":"2.0","result":"2089b05ecca3d829"}`))
+	}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	addr := strings.Split(strings.TrimPrefix(server.URL, "https://"), ":")
+	tlsClient := NewAria2Client("thanks", ClientSetAddr(addr[0]), ClientSetPort(addr[1]),
+		ClientSetRootCAs(pool), ClientSetRPCPath("aria2/jsonrpc"), ClientSetHeader("X-Api-Key", "secret"))
+
+	gid, err := tlsClient.Download("https://dl.google.com/go/go1.18.4.linux-amd64.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gid != "2089b05ecca3d829" {
+		t.Errorf("want 2089b05ecca3d829 got %s", gid)
+	}
+}
+
+func TestTLSOptionOrder(t *testing.T) {
+	pool := x509.NewCertPool()
+	cert := tls.Certificate{Certificate: [][]byte{[]byte("client")}}
+	for _, opts := range [][]Aria2ClientOption{
+		{ClientSetRootCAs(pool), ClientSetCertificate(cert), ClientUseTLS(&tls.Config{ServerName: "aria2"})},
+		{ClientUseTLS(&tls.Config{ServerName: "aria2"}), ClientSetRootCAs(pool), ClientSetCertificate(cert)},
+	} {
+		tlsClient := NewAria2Client("thanks", opts...)
+		config := tlsClient.tlsConfig
+		if config.RootCAs != pool || len(config.Certificates) != 1 || config.ServerName != "aria2" {
+			t.Errorf("tls options lost %+v", config)
+		}
+	}
+}
+
+// idleCloser 记录 CloseIdleConnections 调用次数
+type idleCloser struct {
+	http.RoundTripper
+	closed int
+}
+
+func (c *idleCloser) CloseIdleConnections() {
+	c.closed++
+}
+
+func TestHTTPTransportClose(t *testing.T) {
+	roundTripper := &idleCloser{RoundTripper: http.DefaultTransport}
+	_ = NewAria2Client("thanks", ClientSetHTTPClient(&http.Client{Transport: roundTripper})).Close()
+	_ = NewHTTPTransport("http://127.0.0.1:6800/jsonrpc", &http.Client{Transport: roundTripper}, nil).Close()
+	if roundTripper.closed != 0 {
+		t.Errorf("caller owned client closed %d times", roundTripper.closed)
+	}
+
+	owned := NewAria2Client("thanks", ClientUseTLS(nil), ClientSetRootCAs(x509.NewCertPool()))
+	if transport := owned.transport.(*httpTransport); !transport.owned {
+		t.Error("client created for tls config should be closed by the transport")
+	}
+	if transport := NewAria2Client("thanks").transport.(*httpTransport); transport.owned {
+		t.Error("client using http.DefaultTransport should not be closed")
+	}
+}
+
+func TestRPCError(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jsonrpc":
+			request := &RequestBody{}
+			_ = json.NewDecoder(r.Body).Decode(request)
+			w.WriteHeader(http.StatusBadRequest)
+			if request.Params[0] != "token:thanks" {
+				_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","error":{"code":1,"message":"Unauthorized"}}`, request.ReplayID)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","error":{"code":1,"message":"GID %s is not found"}}`,
+				request.ReplayID, request.Params[1])
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+
+	_, err := newTestClient(t, handler).QueryTaskStatus("2089b05ecca3d829")
+	rpcErr := &RPCError{}
+	if !errors.Is(err, ErrGIDNotFound) || !errors.As(err, &rpcErr) || rpcErr.Method != "aria2.tellStatus" {
+		t.Errorf("want gid not found got %v", err)
+	}
+
+	wrongClient := newTestClient(t, handler)
+	wrongClient.Token = "wrong"
+	_, err = wrongClient.QueryTaskStatus("2089b05ecca3d829")
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("want unauthorized got %v", err)
+	}
+
+	_, err = newTestClient(t, handler, ClientSetRPCPath("/rpc")).QueryTaskStatus("2089b05ecca3d829")
+	transportErr := &TransportError{}
+	if !errors.Is(err, ErrTransport) || !errors.As(err, &transportErr) || transportErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("want transport error got %v", err)
+	}
+}
+
+func TestCall(t *testing.T) {
+	callClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &RequestBody{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","result":{"downloadSpeed":"1024","numActive":"2"}}`, request.ReplayID)
+	}))
+	stat, replayID, err := Call[*GlobalStatData](callClient, NewRequestWithToken(callClient.Token).GetGlobalStat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayID == "" || stat.DownloadSpeed != "1024" || stat.NumActive != "2" {
+		t.Errorf("unexpected result %s %#v", replayID, stat)
+	}
+}
+
+func TestClientMethods(t *testing.T) {
+	results := map[string]string{
+		"aria2.getPeers":           `[{"peerId":"aria2%2F1.36.0","ip":"10.0.0.2","port":"6881","seeder":"true"}]`,
+		"aria2.getServers":         `[{"index":"1","servers":[{"uri":"http://a/b","currentUri":"http://a/b","downloadSpeed":"10"}]}]`,
+		"aria2.changeUri":          `[1,2]`,
+		"aria2.changeGlobalOption": `"OK"`,
+		"aria2.getSessionInfo":     `{"sessionId":"cd6a3bc6a1de28eb5bfa181e5f6b916d44af31a9"}`,
+	}
+	params := make(map[string][]interface{})
+	methodClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &RequestBody{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		params[request.Method] = request.Params
+		_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","result":%s}`, request.ReplayID, results[request.Method])
+	}))
+
+	peers, err := methodClient.GetPeers("2089b05ecca3d829")
+	if err != nil || len(peers) != 1 || peers[0].Ip != "10.0.0.2" {
+		t.Errorf("GetPeers %v %v", peers, err)
+	}
+	servers, err := methodClient.GetServers("2089b05ecca3d829")
+	if err != nil || len(servers) != 1 || servers[0].Servers[0].DownloadSpeed != "10" {
+		t.Errorf("GetServers %v %v", servers, err)
+	}
+	deleted, added, err := methodClient.ChangeUri("2089b05ecca3d829", 1, []string{"http://a/b"}, []string{"http://c/d", "http://e/f"})
+	if err != nil || deleted != 1 || added != 2 {
+		t.Errorf("ChangeUri %d %d %v", deleted, added, err)
+	}
+	session, err := methodClient.GetSessionInfo()
+	if err != nil || session.SessionId != "cd6a3bc6a1de28eb5bfa181e5f6b916d44af31a9" {
+		t.Errorf("GetSessionInfo %v %v", session, err)
+	}
+
+	err = methodClient.ChangeGlobalOption(&Option{MaxDownloadLimit: "1M"}, map[string]string{"max-concurrent-downloads": "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := params["aria2.changeGlobalOption"][1].(map[string]interface{})
+	if options["max-download-limit"] != "1M" || options["max-concurrent-downloads"] != "3" {
+		t.Errorf("ChangeGlobalOption params %v", options)
+	}
+}
+
+// newParamsClient 返回固定 result 的 client,params 记录最后一次请求的参数
+func newParamsClient(t *testing.T, result string, params *[]interface{}) *Aria2Client {
+	t.Helper()
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &RequestBody{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		*params = request.Params
+		_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","result":%s}`, request.ReplayID, result)
+	}))
+}
+
+func TestDownloadMetalink(t *testing.T) {
+	var params []interface{}
+	metalinkClient := newParamsClient(t, `["2089b05ecca3d829","d2703803b52216d1"]`, &params)
+	metalink := `<?xml version="1.0" encoding="UTF-8"?><metalink xmlns="urn:ietf:params:xml:ns:metalink"></metalink>`
+	encoded := base64.StdEncoding.EncodeToString([]byte(metalink))
+
+	cases := []struct {
+		option   *Option
+		position []int
+		want     []interface{}
+	}{
+		{nil, nil, []interface{}{"token:thanks", encoded}},
+		{&Option{Dir: "/data"}, nil, []interface{}{"token:thanks", encoded, map[string]interface{}{"dir": "/data"}}},
+		{&Option{Dir: "/data"}, []int{2}, []interface{}{"token:thanks", encoded, map[string]interface{}{"dir": "/data"}, float64(2)}},
+		// 只设置 position 时需要补充空的 option
+		{nil, []int{0}, []interface{}{"token:thanks", encoded, map[string]interface{}{}, float64(0)}},
+	}
+	for _, item := range cases {
+		gids, err := metalinkClient.DownloadWithMetalink(strings.NewReader(metalink), item.option, item.position...)
+		if err != nil || len(gids) != 2 || gids[1] != "d2703803b52216d1" {
+			t.Errorf("DownloadWithMetalink %v %v", gids, err)
+		}
+		if !reflect.DeepEqual(params, item.want) {
+			t.Errorf("addMetalink params got %v want %v", params, item.want)
+		}
+	}
+
+	if _, err := metalinkClient.DownloadWithMetalink(strings.NewReader(metalink), nil, -1); err == nil {
+		t.Error("want position error")
+	}
+	if _, err := metalinkClient.DownloadWithMetalink(strings.NewReader(metalink), nil, 1, 2); err == nil {
+		t.Error("want position limit error")
+	}
+	if _, err := metalinkClient.DownloadWithMetalink(strings.NewReader(""), nil); err == nil {
+		t.Error("want empty metalink error")
+	}
+}
+
+func TestDownloadTorrentData(t *testing.T) {
+	var params []interface{}
+	torrentClient := newParamsClient(t, `"2089b05ecca3d829"`, &params)
+	torrent := []byte("d4:infod4:name1:aee")
+	encoded := base64.StdEncoding.EncodeToString(torrent)
+
+	cases := []struct {
+		uris     []string
+		option   *Option
+		position []int
+		want     []interface{}
+	}{
+		{nil, nil, nil, []interface{}{"token:thanks", encoded}},
+		{[]string{"http://a/"}, nil, nil, []interface{}{"token:thanks", encoded, []interface{}{"http://a/"}}},
+		// 设置 option 或 position 时需要补充空的 uris 和 option
+		{nil, &Option{Dir: "/data"}, nil, []interface{}{"token:thanks", encoded, []interface{}{}, map[string]interface{}{"dir": "/data"}}},
+		{nil, nil, []int{1}, []interface{}{"token:thanks", encoded, []interface{}{}, map[string]interface{}{}, float64(1)}},
+		{[]string{"http://a/"}, &Option{Dir: "/data"}, []int{0},
+			[]interface{}{"token:thanks", encoded, []interface{}{"http://a/"}, map[string]interface{}{"dir": "/data"}, float64(0)}},
+	}
+	for _, item := range cases {
+		gid, err := torrentClient.DownloadWithTorrentData(torrent, item.uris, item.option, item.position...)
+		if err != nil || gid != "2089b05ecca3d829" {
+			t.Errorf("DownloadWithTorrentData %v %v", gid, err)
+		}
+		if !reflect.DeepEqual(params, item.want) {
+			t.Errorf("addTorrent params got %v want %v", params, item.want)
+		}
+	}
+
+	gid, err := torrentClient.DownloadWithTorrent(bytes.NewReader(torrent), []string{"http://a/"}, nil, 0)
+	want := []interface{}{"token:thanks", encoded, []interface{}{"http://a/"}, map[string]interface{}{}, float64(0)}
+	if err != nil || gid != "2089b05ecca3d829" || !reflect.DeepEqual(params, want) {
+		t.Errorf("DownloadWithTorrent %v %v %v", gid, params, err)
+	}
+	if _, err := torrentClient.DownloadWithTorrentData(torrent, nil, nil, -1); err == nil {
+		t.Error("want position error")
+	}
+	if _, err := torrentClient.DownloadWithTorrentData(nil, nil, nil); err == nil {
+		t.Error("want empty torrent error")
+	}
+}
+
+func TestChangeOption(t *testing.T) {
+	var params []interface{}
+	optionClient := newParamsClient(t, `"OK"`, &params)
+
+	if err := optionClient.ChangeOption("2089b05ecca3d829", &Option{MaxDownloadLimit: "1M"}, map[string]string{"split": "4"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"token:thanks", "2089b05ecca3d829", map[string]interface{}{"max-download-limit": "1M", "split": "4"}}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("changeOption params got %v want %v", params, want)
+	}
+
+	if err := optionClient.ChangeOption("2089b05ecca3d829", nil, map[string]string{"header": "X-A: 1\nX-B: 2"}); err != nil {
+		t.Fatal(err)
+	}
+	want = []interface{}{"token:thanks", "2089b05ecca3d829", map[string]interface{}{"header": []interface{}{"X-A: 1", "X-B: 2"}}}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("changeOption params got %v want %v", params, want)
+	}
+
+	if err := optionClient.ChangeOption("2089b05ecca3d829", nil, map[string]string{"pause": "true"}); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("want invalid option got %v", err)
+	}
+	if err := optionClient.ChangeOption("2089b05ecca3d829", nil, map[string]string{}, map[string]string{}); err == nil {
+		t.Error("want otherOpt limit error")
+	}
+}
+
+func TestMultiCallBatch(t *testing.T) {
+	batchClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &RequestBody{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","result":[["2089b05ecca3d829"],{"code":1,"message":"GID 0000000000000001 is not found"},[{"gid":"2089b05ecca3d829","status":"paused"}]]}`,
+			request.ReplayID)
+	}))
+	batch := batchClient.NewMultiCallBatch()
+	paused := AddMultiCall[string](batch, NewRequestWithToken(batchClient.Token).Pause("2089b05ecca3d829", false))
+	missing := AddMultiCall[string](batch, NewRequestWithToken(batchClient.Token).Pause("0000000000000001", false))
+	status := AddMultiCall[*TaskStatusData](batch, NewRequestWithToken(batchClient.Token).TellStatus("2089b05ecca3d829"))
+	if err := batch.Send(); err != nil {
+		t.Fatal(err)
+	}
+
+	if paused.Err != nil || paused.Result != "2089b05ecca3d829" {
+		t.Errorf("paused %v %v", paused.Result, paused.Err)
+	}
+	rpcErr := &RPCError{}
+	if !errors.Is(missing.Err, ErrGIDNotFound) || !errors.As(missing.Err, &rpcErr) || rpcErr.Method != "aria2.pause" {
+		t.Errorf("missing %v", missing.Err)
+	}
+	if status.Err != nil || status.Result.Status != "paused" {
+		t.Errorf("status %v %v", status.Result, status.Err)
+	}
+}
+
+func TestRequestBatch(t *testing.T) {
+	batchClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests := make([]*RequestBody, 0)
+		_ = json.NewDecoder(r.Body).Decode(&requests)
+
+		// 倒序返回,模拟响应乱序
+		responses := make([]string, 0)
+		for i := len(requests) - 1; i >= 0; i-- {
+			if requests[i].Params[0] != "token:thanks" {
+				responses = append(responses, fmt.Sprintf(`{"id":"%s","jsonrpc":"2.0","error":{"code":1,"message":"Unauthorized"}}`, requests[i].ReplayID))
+				continue
+			}
+			responses = append(responses, fmt.Sprintf(`{"id":"%s","jsonrpc":"2.0","result":"%s"}`, requests[i].ReplayID, requests[i].Params[1]))
+		}
+		_, _ = fmt.Fprintf(w, "[%s]", strings.Join(responses, ","))
+	}))
+	batch := batchClient.NewRequestBatch()
+	first := AddBatchCall[string](batch, NewRequestWithToken(batchClient.Token).Pause("0000000000000001", false))
+	second := AddBatchCall[string](batch, NewRequestWithToken(batchClient.Token).Pause("0000000000000002", false))
+	unauthorized := AddBatchCall[string](batch, NewRequestWithToken("wrong").Pause("0000000000000003", false))
+	if err := batch.Send(); err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Err != nil || first.Result != "0000000000000001" {
+		t.Errorf("first %v %v", first.Result, first.Err)
+	}
+	if second.Err != nil || second.Result != "0000000000000002" {
+		t.Errorf("second %v %v", second.Result, second.Err)
+	}
+	if !errors.Is(unauthorized.Err, ErrUnauthorized) {
+		t.Errorf("unauthorized %v", unauthorized.Err)
+	}
+}
+
+func TestResolveFollowedBy(t *testing.T) {
+	followedBy := map[string]string{
+		"0000000000000001": `["0000000000000002"]`,
+		"0000000000000002": `["0000000000000003","0000000000000004"]`,
+		"0000000000000005": `["0000000000000006"]`,
+		"0000000000000007": `["0000000000000008","0000000000000009"]`,
+	}
+	// 已经被 purgeDownloadResult 删除的任务
+	purged := map[string]bool{"0000000000000006": true, "0000000000000008": true, "0000000000000010": true}
+	resolveClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &RequestBody{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		gid := request.Params[1].(string)
+		if purged[gid] {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","error":{"code":1,"message":"GID %s is not found"}}`, request.ReplayID, gid)
+			return
+		}
+		next, ok := followedBy[gid]
+		if !ok {
+			next = "[]"
+		}
+		_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","result":{"gid":"%s","followedBy":%s}}`, request.ReplayID, gid, next)
+	}))
+
+	cases := map[string][]string{
+		"0000000000000001": {"0000000000000003", "0000000000000004"},
+		"0000000000000005": {"0000000000000005"},
+		"0000000000000007": {"0000000000000009"},
+	}
+	for gid, want := range cases {
+		gids, err := resolveClient.ResolveFollowedBy(gid)
+		if err != nil || !reflect.DeepEqual(gids, want) {
+			t.Errorf("ResolveFollowedBy %s got %v %v want %v", gid, gids, err, want)
+		}
+	}
+	if _, err := resolveClient.ResolveFollowedBy("0000000000000010"); !errors.Is(err, ErrGIDNotFound) {
+		t.Errorf("want gid not found got %v", err)
+	}
+}
@@ -0,0 +1,669 @@
+package aria2test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	aria2go "github.com/gldsly/aria2-go"
+)
+
+// args 去掉 token 之后的请求参数
+type args []interface{}
+
+func (a args) has(i int) bool {
+	return i < len(a) && a[i] != nil
+}
+
+func (a args) string(i int) (string, error) {
+	if !a.has(i) {
+		return "", fmt.Errorf("The parameter at %d is required but missing.", i)
+	}
+	value, ok := a[i].(string)
+	if !ok {
+		return "", fmt.Errorf("The parameter at %d has wrong type.", i)
+	}
+	return value, nil
+}
+
+func (a args) int(i int) (int, error) {
+	if !a.has(i) {
+		return 0, fmt.Errorf("The parameter at %d is required but missing.", i)
+	}
+	value, ok := a[i].(float64)
+	if !ok {
+		return 0, fmt.Errorf("The parameter at %d has wrong type.", i)
+	}
+	return int(value), nil
+}
+
+// optionalInt 可选的整数参数,不存在时返回 def
+func (a args) optionalInt(i int, def int) (int, error) {
+	if !a.has(i) {
+		return def, nil
+	}
+	return a.int(i)
+}
+
+func (a args) strings(i int) ([]string, error) {
+	if !a.has(i) {
+		return nil, nil
+	}
+	items, ok := a[i].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("The parameter at %d has wrong type.", i)
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		value, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("The parameter at %d has wrong type.", i)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// options 解析参数 map,数组值以换行连接,与 aria2 返回的格式一致
+func (a args) options(i int) (map[string]string, error) {
+	result := make(map[string]string)
+	if !a.has(i) {
+		return result, nil
+	}
+	items, ok := a[i].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("The parameter at %d has wrong type.", i)
+	}
+	for key, item := range items {
+		switch value := item.(type) {
+		case string:
+			result[key] = value
+		case []interface{}:
+			joined := ""
+			for j, v := range value {
+				if j > 0 {
+					joined += "\n"
+				}
+				joined += fmt.Sprint(v)
+			}
+			result[key] = joined
+		default:
+			return nil, fmt.Errorf("option %s has wrong type", key)
+		}
+	}
+	return result, nil
+}
+
+// filterOptions 删除不能在 scope 中使用的参数
+// 与 aria2 的 gatherOption 相同,未知或不在 scope 中的参数被忽略而不是返回错误
+func filterOptions(scope aria2go.OptionScope, options map[string]string) {
+	for key := range options {
+		if !aria2go.OptionAllowed(scope, key) {
+			delete(options, key)
+		}
+	}
+}
+
+type methodHandler func(s *Server, a args) (interface{}, error)
+
+var methods = map[string]methodHandler{
+	"aria2.addUri":               (*Server).addUri,
+	"aria2.addTorrent":           (*Server).addTorrent,
+	"aria2.addMetalink":          (*Server).addMetalink,
+	"aria2.remove":               (*Server).remove,
+	"aria2.forceRemove":          (*Server).remove,
+	"aria2.pause":                (*Server).pause,
+	"aria2.forcePause":           (*Server).pause,
+	"aria2.pauseAll":             (*Server).pauseAll,
+	"aria2.forcePauseAll":        (*Server).pauseAll,
+	"aria2.unpause":              (*Server).unpause,
+	"aria2.unpauseAll":           (*Server).unpauseAll,
+	"aria2.tellStatus":           (*Server).tellStatus,
+	"aria2.getUris":              (*Server).getUris,
+	"aria2.getFiles":             (*Server).getFiles,
+	"aria2.getPeers":             (*Server).getPeers,
+	"aria2.getServers":           (*Server).getServers,
+	"aria2.tellActive":           (*Server).tellActive,
+	"aria2.tellWaiting":          (*Server).tellWaiting,
+	"aria2.tellStopped":          (*Server).tellStopped,
+	"aria2.changePosition":       (*Server).changePosition,
+	"aria2.changeUri":            (*Server).changeUri,
+	"aria2.getOption":            (*Server).getOption,
+	"aria2.changeOption":         (*Server).changeOption,
+	"aria2.getGlobalOption":      (*Server).getGlobalOption,
+	"aria2.changeGlobalOption":   (*Server).changeGlobalOption,
+	"aria2.getGlobalStat":        (*Server).getGlobalStat,
+	"aria2.purgeDownloadResult":  (*Server).purgeDownloadResult,
+	"aria2.removeDownloadResult": (*Server).removeDownloadResult,
+	"aria2.getVersion":           (*Server).getVersion,
+	"aria2.getSessionInfo":       (*Server).getSessionInfo,
+	"aria2.shutdown":             (*Server).shutdownServer,
+	"aria2.forceShutdown":        (*Server).shutdownServer,
+	"aria2.saveSession":          (*Server).saveSession,
+}
+
+// methodNames 所有支持的方法名
+func methodNames() []string {
+	names := []string{"system.multicall", "system.listMethods", "system.listNotifications"}
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newTaskLocked 根据参数创建任务,gid 参数存在时使用指定的 gid
+func (s *Server) newTaskLocked(uris []string, options map[string]string) (*task, error) {
+	filterOptions(aria2go.OPTION_SCOPE_INPUT_FILE, options)
+	gid := options["gid"]
+	delete(options, "gid")
+	if gid == "" {
+		gid = s.newGidLocked()
+	} else if _, ok := s.tasks[gid]; ok {
+		return nil, fmt.Errorf("GID %s is not unique.", gid)
+	}
+	return &task{gid: gid, uris: uris, options: options}, nil
+}
+
+func (s *Server) addUri(a args) (interface{}, error) {
+	uris, err := a.strings(0)
+	if err != nil {
+		return nil, err
+	}
+	if len(uris) == 0 {
+		return nil, errors.New("URI is not provided.")
+	}
+	options, err := a.options(1)
+	if err != nil {
+		return nil, err
+	}
+	position, err := a.optionalInt(2, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.newTaskLocked(uris, options)
+	if err != nil {
+		return nil, err
+	}
+	s.addTaskLocked(t, position)
+	return t.gid, nil
+}
+
+// torrentNamePattern 从 bencode 数据中读取 name 字段
+var torrentNamePattern = regexp.MustCompile(`4:name(\d+):`)
+
+func (s *Server) addTorrent(a args) (interface{}, error) {
+	encoded, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("Torrent data is not provided.")
+	}
+	uris, err := a.strings(1)
+	if err != nil {
+		return nil, err
+	}
+	options, err := a.options(2)
+	if err != nil {
+		return nil, err
+	}
+	position, err := a.optionalInt(3, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.newTaskLocked(uris, options)
+	if err != nil {
+		return nil, err
+	}
+	t.torrent = true
+	if match := torrentNamePattern.FindSubmatchIndex(data); match != nil {
+		length, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+		if end := match[1] + length; end <= len(data) {
+			t.name = string(data[match[1]:end])
+		}
+	}
+	s.addTaskLocked(t, position)
+	return t.gid, nil
+}
+
+// metalinkFilePattern metalink 中的文件
+var metalinkFilePattern = regexp.MustCompile(`<file\s+name="([^"]*)"`)
+
+func (s *Server) addMetalink(a args) (interface{}, error) {
+	encoded, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("Metalink data is not provided.")
+	}
+	options, err := a.options(1)
+	if err != nil {
+		return nil, err
+	}
+	position, err := a.optionalInt(2, -1)
+	if err != nil {
+		return nil, err
+	}
+	filterOptions(aria2go.OPTION_SCOPE_INPUT_FILE, options)
+
+	files := metalinkFilePattern.FindAllSubmatch(data, -1)
+	if len(files) == 0 {
+		return nil, errors.New("No files to download.")
+	}
+	gids := make([]string, 0, len(files))
+	for _, file := range files {
+		taskOptions := make(map[string]string, len(options))
+		for key, value := range options {
+			taskOptions[key] = value
+		}
+		delete(taskOptions, "gid")
+		t := &task{gid: s.newGidLocked(), name: string(file[1]), options: taskOptions}
+		s.addTaskLocked(t, position)
+		if position >= 0 {
+			position++
+		}
+		gids = append(gids, t.gid)
+	}
+	return gids, nil
+}
+
+func (s *Server) remove(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findLocked(gid)
+	if err != nil {
+		return nil, err
+	}
+	if t.status == aria2go.STATUS_COMPLETE || t.status == aria2go.STATUS_ERROR || t.status == aria2go.STATUS_REMOVED {
+		return nil, fmt.Errorf("Active Download not found for GID#%s", gid)
+	}
+	s.stopLocked(t, aria2go.STATUS_REMOVED)
+	return gid, nil
+}
+
+func (s *Server) pause(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findLocked(gid)
+	if err != nil {
+		return nil, err
+	}
+	if t.status != aria2go.STATUS_ACTIVE && t.status != aria2go.STATUS_WAITING {
+		return nil, fmt.Errorf("GID#%s cannot be paused now", gid)
+	}
+	s.pauseLocked(t, 0)
+	s.scheduleLocked()
+	return gid, nil
+}
+
+func (s *Server) pauseAll(a args) (interface{}, error) {
+	// 正在下载的任务按原来的顺序排在等待队列最前面
+	gids := append(append([]string{}, s.active...), s.waiting...)
+	for i, gid := range gids {
+		s.pauseLocked(s.tasks[gid], i)
+	}
+	return "OK", nil
+}
+
+func (s *Server) unpause(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findLocked(gid)
+	if err != nil {
+		return nil, err
+	}
+	if t.status != aria2go.STATUS_PAUSED {
+		return nil, fmt.Errorf("GID#%s cannot be unpaused now", gid)
+	}
+	t.status = aria2go.STATUS_WAITING
+	s.scheduleLocked()
+	return gid, nil
+}
+
+func (s *Server) unpauseAll(a args) (interface{}, error) {
+	for _, gid := range s.waiting {
+		if t := s.tasks[gid]; t.status == aria2go.STATUS_PAUSED {
+			t.status = aria2go.STATUS_WAITING
+		}
+	}
+	s.scheduleLocked()
+	return "OK", nil
+}
+
+func (s *Server) tellStatus(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := a.strings(1)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findLocked(gid)
+	if err != nil {
+		return nil, err
+	}
+	return t.statusMap(s.global, keys), nil
+}
+
+func (s *Server) getUris(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findLocked(gid)
+	if err != nil {
+		return nil, err
+	}
+	return t.files(s.global)[0]["uris"], nil
+}
+
+func (s *Server) getFiles(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findLocked(gid)
+	if err != nil {
+		return nil, err
+	}
+	return t.files(s.global), nil
+}
+
+func (s *Server) getPeers(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.findLocked(gid); err != nil {
+		return nil, err
+	}
+	return []interface{}{}, nil
+}
+
+func (s *Server) getServers(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findLocked(gid)
+	if err != nil {
+		return nil, err
+	}
+	if t.status != aria2go.STATUS_ACTIVE {
+		return nil, fmt.Errorf("No active download for GID#%s", gid)
+	}
+	servers := make([]map[string]string, 0, 1)
+	if len(t.uris) > 0 {
+		servers = append(servers, map[string]string{
+			"uri":           t.uris[0],
+			"currentUri":    t.uris[0],
+			"downloadSpeed": strconv.FormatInt(t.downloadSpeed, 10),
+		})
+	}
+	return []map[string]interface{}{{"index": "1", "servers": servers}}, nil
+}
+
+func (s *Server) tellActive(a args) (interface{}, error) {
+	keys, err := a.strings(0)
+	if err != nil {
+		return nil, err
+	}
+	return s.statusList(s.active, keys), nil
+}
+
+func (s *Server) tellWaiting(a args) (interface{}, error) {
+	return s.tellPage(s.waiting, a)
+}
+
+func (s *Server) tellStopped(a args) (interface{}, error) {
+	return s.tellPage(s.stopped, a)
+}
+
+// tellPage 按 offset num 返回任务,offset 为负数时从末尾开始倒序返回
+func (s *Server) tellPage(list []string, a args) (interface{}, error) {
+	offset, err := a.int(0)
+	if err != nil {
+		return nil, err
+	}
+	num, err := a.int(1)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := a.strings(2)
+	if err != nil {
+		return nil, err
+	}
+
+	page := make([]string, 0)
+	if offset < 0 {
+		for i := len(list) + offset; i >= 0 && len(page) < num; i-- {
+			page = append(page, list[i])
+		}
+	} else {
+		for i := offset; i < len(list) && len(page) < num; i++ {
+			page = append(page, list[i])
+		}
+	}
+	return s.statusList(page, keys), nil
+}
+
+func (s *Server) statusList(gids []string, keys []string) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(gids))
+	for _, gid := range gids {
+		result = append(result, s.tasks[gid].statusMap(s.global, keys))
+	}
+	return result
+}
+
+func (s *Server) changePosition(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	pos, err := a.int(1)
+	if err != nil {
+		return nil, err
+	}
+	how, err := a.string(2)
+	if err != nil {
+		return nil, err
+	}
+
+	waiting, current := removeGid(s.waiting, gid)
+	if current < 0 {
+		return nil, fmt.Errorf("GID#%s not found in the waiting queue.", gid)
+	}
+	switch aria2go.PositionOpt(how) {
+	case aria2go.POS_SET:
+	case aria2go.POS_CUR:
+		pos += current
+	case aria2go.POS_END:
+		pos += len(waiting)
+	default:
+		return nil, errors.New("Illegal argument.")
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > len(waiting) {
+		pos = len(waiting)
+	}
+	s.waiting = insertGid(waiting, gid, pos)
+	return pos, nil
+}
+
+func (s *Server) changeUri(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	fileIndex, err := a.int(1)
+	if err != nil {
+		return nil, err
+	}
+	delUris, err := a.strings(2)
+	if err != nil {
+		return nil, err
+	}
+	addUris, err := a.strings(3)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findLocked(gid)
+	if err != nil {
+		return nil, err
+	}
+	if fileIndex != 1 {
+		return nil, errors.New("fileIndex is out of range")
+	}
+
+	deleted := 0
+	for _, uri := range delUris {
+		for i, item := range t.uris {
+			if item == uri {
+				t.uris = append(t.uris[:i:i], t.uris[i+1:]...)
+				deleted++
+				break
+			}
+		}
+	}
+	t.uris = append(t.uris, addUris...)
+	return []int{deleted, len(addUris)}, nil
+}
+
+func (s *Server) getOption(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findLocked(gid)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(t.options)+1)
+	for key, value := range s.global {
+		if aria2go.OptionAllowed(aria2go.OPTION_SCOPE_INPUT_FILE, key) {
+			result[key] = value
+		}
+	}
+	for key, value := range t.options {
+		result[key] = value
+	}
+	result["dir"] = t.dir(s.global)
+	return result, nil
+}
+
+func (s *Server) changeOption(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	options, err := a.options(1)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.findLocked(gid)
+	if err != nil {
+		return nil, err
+	}
+	filterOptions(aria2go.OPTION_SCOPE_CHANGE, options)
+	for key, value := range options {
+		t.options[key] = value
+	}
+	return "OK", nil
+}
+
+func (s *Server) getGlobalOption(a args) (interface{}, error) {
+	result := make(map[string]string, len(s.global))
+	for key, value := range s.global {
+		result[key] = value
+	}
+	return result, nil
+}
+
+func (s *Server) changeGlobalOption(a args) (interface{}, error) {
+	options, err := a.options(0)
+	if err != nil {
+		return nil, err
+	}
+	filterOptions(aria2go.OPTION_SCOPE_GLOBAL, options)
+	for key, value := range options {
+		s.global[key] = value
+	}
+	s.scheduleLocked()
+	return "OK", nil
+}
+
+func (s *Server) getGlobalStat(a args) (interface{}, error) {
+	downloadSpeed, uploadSpeed := int64(0), int64(0)
+	for _, gid := range s.active {
+		downloadSpeed += s.tasks[gid].downloadSpeed
+		uploadSpeed += s.tasks[gid].uploadSpeed
+	}
+	return map[string]string{
+		"downloadSpeed":   strconv.FormatInt(downloadSpeed, 10),
+		"uploadSpeed":     strconv.FormatInt(uploadSpeed, 10),
+		"numActive":       strconv.Itoa(len(s.active)),
+		"numWaiting":      strconv.Itoa(len(s.waiting)),
+		"numStopped":      strconv.Itoa(len(s.stopped)),
+		"numStoppedTotal": strconv.Itoa(s.numStoppedTotal),
+	}, nil
+}
+
+func (s *Server) purgeDownloadResult(a args) (interface{}, error) {
+	for _, gid := range s.stopped {
+		delete(s.tasks, gid)
+	}
+	s.stopped = nil
+	return "OK", nil
+}
+
+func (s *Server) removeDownloadResult(a args) (interface{}, error) {
+	gid, err := a.string(0)
+	if err != nil {
+		return nil, err
+	}
+	stopped, index := removeGid(s.stopped, gid)
+	if index < 0 {
+		return nil, fmt.Errorf("Could not remove download result of GID#%s", gid)
+	}
+	s.stopped = stopped
+	delete(s.tasks, gid)
+	return "OK", nil
+}
+
+func (s *Server) getVersion(a args) (interface{}, error) {
+	return &aria2go.GetVersionResponse{
+		Version:         "1.37.0",
+		EnabledFeatures: []string{"Async DNS", "BitTorrent", "GZip", "HTTPS", "Message Digest", "Metalink", "XML-RPC"},
+	}, nil
+}
+
+func (s *Server) getSessionInfo(a args) (interface{}, error) {
+	return map[string]string{"sessionId": "cd6a3bc6a1de28eb5bfa181e5f6b916d44af31a9"}, nil
+}
+
+func (s *Server) shutdownServer(a args) (interface{}, error) {
+	s.shutdown = true
+	return "OK", nil
+}
+
+func (s *Server) saveSession(a args) (interface{}, error) {
+	return "OK", nil
+}
@@ -0,0 +1,256 @@
+package aria2test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	aria2go "github.com/gldsly/aria2-go"
+)
+
+// REDACTED 录制时替换 token 和 rpc-secret 的值
+const REDACTED = "REDACTED"
+
+// Interaction 录制的一次请求和响应
+type Interaction struct {
+	// Request 去掉 id 并隐藏 token 之后的请求,batch 请求为数组
+	Request interface{} `json:"request"`
+	// IDs 录制时请求的 id,回放时替换为新请求的 id
+	IDs      []string    `json:"ids"`
+	Response interface{} `json:"response,omitempty"`
+	// Error 传输层错误,录制时请求失败才有值
+	Error string `json:"error,omitempty"`
+}
+
+// Recorder 录制经过的所有请求和响应,Close 时写入 golden 文件
+//
+//	recorder := aria2test.NewRecorder(aria2go.NewHTTPTransport("http://127.0.0.1:6800/jsonrpc", nil, nil), "testdata/download.json")
+//	client := aria2go.NewAria2Client("thanks", aria2go.ClientSetTransport(recorder))
+//	defer client.Close()
+type Recorder struct {
+	transport aria2go.Transport
+	path      string
+
+	mu           sync.Mutex
+	interactions []*Interaction
+}
+
+// NewRecorder 创建录制传输层,请求通过 transport 发送,结果写入 path
+func NewRecorder(transport aria2go.Transport, path string) *Recorder {
+	return &Recorder{transport: transport, path: path}
+}
+
+func (r *Recorder) SendRequest(ctx context.Context, body []byte) ([]byte, error) {
+	request, ids, err := normalizeRequest(body)
+	if err != nil {
+		return nil, err
+	}
+
+	result, sendErr := r.transport.SendRequest(ctx, body)
+	interaction := &Interaction{Request: request, IDs: ids}
+	if sendErr != nil {
+		interaction.Error = sendErr.Error()
+	} else {
+		response := interface{}(nil)
+		if err := json.Unmarshal(result, &response); err != nil {
+			response = string(result)
+		}
+		interaction.Response = redactResponse(response)
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+	return result, sendErr
+}
+
+// Save 将已录制的内容写入 golden 文件
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// Close 写入 golden 文件并关闭被录制的传输层
+func (r *Recorder) Close() error {
+	if err := r.Save(); err != nil {
+		_ = r.transport.Close()
+		return err
+	}
+	return r.transport.Close()
+}
+
+// Replayer 按照 method 和 params 回放 golden 文件中的响应
+// 相同的请求按录制顺序依次返回,全部返回后重复最后一次的响应,可以用于回放轮询
+type Replayer struct {
+	mu      sync.Mutex
+	entries map[string][]*Interaction
+}
+
+// NewReplayer 读取 golden 文件创建回放传输层
+func NewReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	interactions := make([]*Interaction, 0)
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("aria2test: parse golden file %s: %w", path, err)
+	}
+
+	replayer := &Replayer{entries: make(map[string][]*Interaction)}
+	for _, interaction := range interactions {
+		key, err := json.Marshal(interaction.Request)
+		if err != nil {
+			return nil, err
+		}
+		replayer.entries[string(key)] = append(replayer.entries[string(key)], interaction)
+	}
+	return replayer, nil
+}
+
+func (r *Replayer) SendRequest(ctx context.Context, body []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	request, ids, err := normalizeRequest(body)
+	if err != nil {
+		return nil, err
+	}
+	key, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	entries := r.entries[string(key)]
+	if len(entries) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("aria2test: no recorded response for %s", key)
+	}
+	interaction := entries[0]
+	if len(entries) > 1 {
+		r.entries[string(key)] = entries[1:]
+	}
+	r.mu.Unlock()
+
+	if interaction.Error != "" {
+		return nil, errors.New(interaction.Error)
+	}
+	if text, ok := interaction.Response.(string); ok {
+		return []byte(text), nil
+	}
+
+	// 录制时的 id 替换为当前请求的 id
+	replace := make(map[string]string, len(ids))
+	for i, id := range interaction.IDs {
+		if i < len(ids) {
+			replace[id] = ids[i]
+		}
+	}
+	return json.Marshal(replaceIDs(interaction.Response, replace))
+}
+
+func (r *Replayer) Close() error {
+	return nil
+}
+
+// normalizeRequest 去掉请求中的 id 并隐藏 token,返回处理后的请求和原始的 id
+func normalizeRequest(body []byte) (request interface{}, ids []string, err error) {
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil, nil, fmt.Errorf("aria2test: parse request: %w", err)
+	}
+
+	normalize := func(item interface{}) interface{} {
+		call, ok := item.(map[string]interface{})
+		if !ok {
+			return item
+		}
+		ids = append(ids, fmt.Sprint(call["id"]))
+		return map[string]interface{}{
+			"method": call["method"],
+			"params": redactParams(call["params"]),
+		}
+	}
+
+	if batch, ok := request.([]interface{}); ok {
+		result := make([]interface{}, 0, len(batch))
+		for _, item := range batch {
+			result = append(result, normalize(item))
+		}
+		return result, ids, nil
+	}
+	return normalize(request), ids, nil
+}
+
+// redactParams 隐藏 params 中的 token,包括 system.multicall 中每个请求的 token
+func redactParams(params interface{}) interface{} {
+	switch value := params.(type) {
+	case string:
+		if strings.HasPrefix(value, "token:") {
+			return "token:" + REDACTED
+		}
+	case []interface{}:
+		result := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			result = append(result, redactParams(item))
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			result[key] = redactParams(item)
+		}
+		return result
+	}
+	return params
+}
+
+// redactResponse 隐藏 getGlobalOption 等响应中的 rpc-secret
+func redactResponse(response interface{}) interface{} {
+	switch value := response.(type) {
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactResponse(item)
+		}
+	case map[string]interface{}:
+		for key, item := range value {
+			if key == "rpc-secret" {
+				value[key] = REDACTED
+				continue
+			}
+			value[key] = redactResponse(item)
+		}
+	}
+	return response
+}
+
+// replaceIDs 替换响应中的 id,batch 响应为数组
+func replaceIDs(response interface{}, replace map[string]string) interface{} {
+	switch value := response.(type) {
+	case []interface{}:
+		result := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			result = append(result, replaceIDs(item, replace))
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			result[key] = item
+		}
+		if id, ok := replace[fmt.Sprint(value["id"])]; ok {
+			result["id"] = id
+		}
+		return result
+	}
+	return response
+}
@@ -0,0 +1,76 @@
+package aria2test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	aria2go "github.com/gldsly/aria2-go"
+)
+
+func TestRecordReplay(t *testing.T) {
+	server := NewServer("thanks")
+	defer server.Close()
+	golden := filepath.Join(t.TempDir(), "download.json")
+
+	run := func(client *aria2go.Aria2Client) (string, *aria2go.TaskStatusData, error) {
+		gid, err := client.DownloadUris([]string{"http://a/1.iso"}, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		status, err := client.QueryTaskStatusFields(gid, aria2go.FIELDS_PROGRESS)
+		if err != nil {
+			return "", nil, err
+		}
+		batch := client.NewRequestBatch()
+		stat := aria2go.AddBatchCall[*aria2go.GlobalStatData](batch, aria2go.NewRequestWithToken(client.Token).GetGlobalStat())
+		missing := aria2go.AddBatchCall[*aria2go.TaskStatusData](batch, aria2go.NewRequestWithToken(client.Token).TellStatus("0000000000000000"))
+		if err := batch.Send(); err != nil {
+			return "", nil, err
+		}
+		if stat.Err != nil || stat.Result.NumActive != "1" || !errors.Is(missing.Err, aria2go.ErrGIDNotFound) {
+			return "", nil, errors.New("unexpected batch result")
+		}
+		return gid, status, nil
+	}
+
+	recorder := NewRecorder(aria2go.NewHTTPTransport(server.URL+aria2go.DEFAULT_RPC_PATH, nil, nil), golden)
+	recordGid, recordStatus, err := run(aria2go.NewAria2Client("thanks", aria2go.ClientSetTransport(recorder)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "token:thanks") || !strings.Contains(string(data), "token:"+REDACTED) {
+		t.Errorf("token is not redacted:\n%s", data)
+	}
+
+	replayer, err := NewReplayer(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayGid, replayStatus, err := run(aria2go.NewAria2Client("another", aria2go.ClientSetTransport(replayer)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayGid != recordGid || replayStatus.Status != recordStatus.Status {
+		t.Errorf("replay %s %v record %s %v", replayGid, replayStatus, recordGid, recordStatus)
+	}
+
+	// 相同请求的响应全部回放后重复最后一次
+	replayClient := aria2go.NewAria2Client("another", aria2go.ClientSetTransport(replayer))
+	if _, err := replayClient.QueryTaskStatusFields(recordGid, aria2go.FIELDS_PROGRESS); err != nil {
+		t.Error(err)
+	}
+	if _, err := replayClient.GetVersion(); !errors.Is(err, aria2go.ErrTransport) {
+		t.Errorf("want missing recording error got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package aria2test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	aria2go "github.com/gldsly/aria2-go"
+)
+
+// update 在锁内修改任务,完成后发送通知
+func (s *Server) update(gid string, fn func(t *task) error) error {
+	s.mu.Lock()
+	t, err := s.findLocked(gid)
+	if err == nil {
+		err = fn(t)
+	}
+	s.mu.Unlock()
+	s.flush()
+	return err
+}
+
+// SetTotalLength 设置任务的总大小
+func (s *Server) SetTotalLength(gid string, totalLength int64) error {
+	return s.update(gid, func(t *task) error {
+		t.totalLength = totalLength
+		return nil
+	})
+}
+
+// SetProgress 设置任务的已下载大小和下载速度,任务不会自动完成
+func (s *Server) SetProgress(gid string, completedLength, downloadSpeed int64) error {
+	return s.update(gid, func(t *task) error {
+		if t.status != aria2go.STATUS_ACTIVE {
+			return fmt.Errorf("GID %s is not active", gid)
+		}
+		t.completedLength = completedLength
+		t.downloadSpeed = downloadSpeed
+		return nil
+	})
+}
+
+// SetUpload 设置任务的上传量和上传速度
+func (s *Server) SetUpload(gid string, uploadLength, uploadSpeed int64) error {
+	return s.update(gid, func(t *task) error {
+		t.uploadLength = uploadLength
+		t.uploadSpeed = uploadSpeed
+		return nil
+	})
+}
+
+// Tick 模拟经过一秒,所有正在下载的任务按下载速度增加已下载大小
+// 已下载大小达到总大小的任务会完成
+func (s *Server) Tick() {
+	s.mu.Lock()
+	active := append([]string{}, s.active...)
+	for _, gid := range active {
+		t := s.tasks[gid]
+		t.completedLength += t.downloadSpeed
+		t.uploadLength += t.uploadSpeed
+		if t.totalLength > 0 && t.completedLength >= t.totalLength {
+			t.completedLength = t.totalLength
+			s.stopLocked(t, aria2go.STATUS_COMPLETE)
+		}
+	}
+	s.mu.Unlock()
+	s.flush()
+}
+
+// Complete 完成任务,已下载大小设置为总大小
+func (s *Server) Complete(gid string) error {
+	return s.update(gid, func(t *task) error {
+		if t.status != aria2go.STATUS_ACTIVE {
+			return fmt.Errorf("GID %s is not active", gid)
+		}
+		t.completedLength = t.totalLength
+		s.stopLocked(t, aria2go.STATUS_COMPLETE)
+		return nil
+	})
+}
+
+// CompleteWithFollowedBy 完成任务并生成一个 BitTorrent 任务,返回新任务的 gid
+// 用于模拟 magnet 或 .torrent 链接下载完成后生成真正的下载任务
+func (s *Server) CompleteWithFollowedBy(gid string, name string) (string, error) {
+	child := ""
+	err := s.update(gid, func(t *task) error {
+		if t.status != aria2go.STATUS_ACTIVE {
+			return fmt.Errorf("GID %s is not active", gid)
+		}
+		next := &task{gid: s.newGidLocked(), name: name, torrent: true, following: gid, options: make(map[string]string)}
+		for key, value := range t.options {
+			next.options[key] = value
+		}
+		child = next.gid
+		t.followedBy = append(t.followedBy, child)
+		t.completedLength = t.totalLength
+		s.stopLocked(t, aria2go.STATUS_COMPLETE)
+		s.addTaskLocked(next, -1)
+		return nil
+	})
+	return child, err
+}
+
+// Fail 任务下载失败,code message 对应 tellStatus 中的 errorCode errorMessage
+func (s *Server) Fail(gid string, code int, message string) error {
+	return s.update(gid, func(t *task) error {
+		if t.status != aria2go.STATUS_ACTIVE && t.status != aria2go.STATUS_WAITING {
+			return fmt.Errorf("GID %s is not active", gid)
+		}
+		t.errorCode = code
+		t.errorMessage = message
+		s.stopLocked(t, aria2go.STATUS_ERROR)
+		return nil
+	})
+}
+
+// Task 查询任务当前的状态
+func (s *Server) Task(gid string) (*aria2go.TaskStatusData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[gid]
+	if !ok {
+		return nil, false
+	}
+
+	status := &aria2go.TaskStatusData{}
+	data := s.encode(t.statusMap(s.global, nil))
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, false
+	}
+	return status, true
+}
+
+// TaskOption 查询任务的参数
+func (s *Server) TaskOption(gid, key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tasks[gid]; ok {
+		return t.options[key]
+	}
+	return ""
+}
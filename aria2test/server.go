@@ -0,0 +1,420 @@
+// Package aria2test 提供基于 httptest 的 aria2 jsonrpc 模拟服务,用于在没有 aria2c 的环境中测试
+//
+//	server := aria2test.NewServer("thanks")
+//	defer server.Close()
+//
+//	client := server.Client()
+//	gid, _ := client.Download("http://example.com/a.iso")
+//	_ = server.SetTotalLength(gid, 1024)
+//	_ = server.SetProgress(gid, 512, 256)
+//	_ = server.Complete(gid)
+package aria2test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+
+	aria2go "github.com/gldsly/aria2-go"
+	"github.com/gorilla/websocket"
+)
+
+// Server 模拟的 aria2 服务
+// 支持 http 和 websocket 请求,jsonrpc batch,system.multicall 和 websocket 通知
+// 任务状态只在服务端内存中,通过 SetProgress Complete Fail 等方法控制下载进度
+type Server struct {
+	// URL http 地址,例如 http://127.0.0.1:50000
+	URL   string
+	Token string
+
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+
+	mu              sync.Mutex
+	tasks           map[string]*task
+	active          []string
+	waiting         []string
+	stopped         []string
+	numStoppedTotal int
+	global          map[string]string
+	nextGid         uint64
+	faults          map[string][]*aria2go.ResponseError
+	httpFaults      []int
+	conns           map[*wsConn]bool
+	pending         []notification
+	shutdown        bool
+}
+
+// notification 等待发送的通知
+type notification struct {
+	method aria2go.NotificationType
+	gid    string
+}
+
+// wsConn websocket 连接,写入需要加锁
+type wsConn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+func (c *wsConn) write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// NewServer 启动模拟的 aria2 服务,token 为空时不校验访问令牌
+func NewServer(token string) *Server {
+	s := &Server{
+		Token:   token,
+		tasks:   make(map[string]*task),
+		global:  defaultGlobalOptions(),
+		nextGid: 0x2089b05ecca3d829,
+		faults:  make(map[string][]*aria2go.ResponseError),
+		conns:   make(map[*wsConn]bool),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+func defaultGlobalOptions() map[string]string {
+	return map[string]string{
+		"dir":                        "/downloads",
+		"max-concurrent-downloads":   "5",
+		"max-connection-per-server":  "1",
+		"split":                      "5",
+		"max-overall-download-limit": "0",
+		"max-overall-upload-limit":   "0",
+		"log-level":                  "debug",
+	}
+}
+
+// Close 关闭服务和所有 websocket 连接
+func (s *Server) Close() {
+	s.mu.Lock()
+	for conn := range s.conns {
+		_ = conn.conn.Close()
+	}
+	s.mu.Unlock()
+	s.server.Close()
+}
+
+// Client 创建连接到模拟服务的 Aria2Client
+func (s *Server) Client(opt ...aria2go.Aria2ClientOption) *aria2go.Aria2Client {
+	host, port, _ := net.SplitHostPort(s.server.Listener.Addr().String())
+	opts := []aria2go.Aria2ClientOption{aria2go.ClientSetAddr(host), aria2go.ClientSetPort(port)}
+	return aria2go.NewAria2Client(s.Token, append(opts, opt...)...)
+}
+
+// FailNext 下一次调用 method 时返回错误,多次调用依次生效
+// method 为完整方法名,例如 aria2.tellStatus
+func (s *Server) FailNext(method string, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[method] = append(s.faults[method], &aria2go.ResponseError{Code: code, Message: message})
+}
+
+// FailNextHTTP 下一次 http 请求返回 statusCode,多次调用依次生效
+func (s *Server) FailNextHTTP(statusCode int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.httpFaults = append(s.httpFaults, statusCode)
+}
+
+// IsShutdown 是否调用过 aria2.shutdown 或 aria2.forceShutdown
+func (s *Server) IsShutdown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shutdown
+}
+
+// GlobalOption 获取全局参数
+func (s *Server) GlobalOption(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.global[key]
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveWebsocket(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	if len(s.httpFaults) > 0 {
+		code := s.httpFaults[0]
+		s.httpFaults = s.httpFaults[1:]
+		s.mu.Unlock()
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+	s.mu.Unlock()
+
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	buf := &bytes.Buffer{}
+	if _, err := buf.ReadFrom(r.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, status := s.handle(buf.Bytes())
+	w.Header().Set("Content-Type", aria2go.DEFAULT_CONTENT_TYPE)
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
+
+func (s *Server) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &wsConn{conn: conn}
+	s.mu.Lock()
+	s.conns[c] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		data, _ := s.handle(message)
+		if err := c.write(data); err != nil {
+			return
+		}
+	}
+}
+
+// rpcRequest jsonrpc 请求
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params []interface{}   `json:"params"`
+}
+
+// rpcResponse jsonrpc 响应
+type rpcResponse struct {
+	JSONRPC string                 `json:"jsonrpc"`
+	ID      json.RawMessage        `json:"id"`
+	Result  interface{}            `json:"result,omitempty"`
+	Error   *aria2go.ResponseError `json:"error,omitempty"`
+}
+
+// handle 处理单个请求或者 batch 请求,返回响应数据和 http 状态码
+func (s *Server) handle(body []byte) ([]byte, int) {
+	defer s.flush()
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		requests := make([]*rpcRequest, 0)
+		if err := json.Unmarshal(body, &requests); err != nil {
+			return s.encode(&rpcResponse{Error: parseError()}), http.StatusBadRequest
+		}
+		responses := make([]*rpcResponse, 0, len(requests))
+		for _, request := range requests {
+			responses = append(responses, s.dispatch(request))
+		}
+		return s.encode(responses), http.StatusOK
+	}
+
+	request := &rpcRequest{}
+	if err := json.Unmarshal(body, request); err != nil {
+		return s.encode(&rpcResponse{Error: parseError()}), http.StatusBadRequest
+	}
+	response := s.dispatch(request)
+	if response.Error != nil {
+		// aria2 对错误响应返回 400
+		return s.encode(response), http.StatusBadRequest
+	}
+	return s.encode(response), http.StatusOK
+}
+
+func (s *Server) encode(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(&rpcResponse{JSONRPC: aria2go.DEFAULT_JSONRPC_VERSION, Error: &aria2go.ResponseError{Code: -32603, Message: err.Error()}})
+	}
+	return data
+}
+
+func parseError() *aria2go.ResponseError {
+	return &aria2go.ResponseError{Code: -32700, Message: "Parse error."}
+}
+
+// dispatch 处理单个请求
+func (s *Server) dispatch(request *rpcRequest) *rpcResponse {
+	response := &rpcResponse{JSONRPC: aria2go.DEFAULT_JSONRPC_VERSION, ID: request.ID}
+	if response.ID == nil {
+		response.ID = json.RawMessage("null")
+	}
+	result, err := s.call(request.Method, request.Params)
+	if err != nil {
+		response.Error = err
+		return response
+	}
+	response.Result = result
+	return response
+}
+
+// call 加锁后调用方法
+func (s *Server) call(method string, params []interface{}) (interface{}, *aria2go.ResponseError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.callLocked(method, params)
+}
+
+// callLocked 校验 token 后调用方法,调用方需要持有 mu
+func (s *Server) callLocked(method string, params []interface{}) (interface{}, *aria2go.ResponseError) {
+	if faults := s.faults[method]; len(faults) > 0 {
+		s.faults[method] = faults[1:]
+		return nil, faults[0]
+	}
+
+	switch method {
+	case "system.listMethods":
+		return methodNames(), nil
+	case "system.listNotifications":
+		return []aria2go.NotificationType{
+			aria2go.ON_DOWNLOAD_START, aria2go.ON_DOWNLOAD_PAUSE, aria2go.ON_DOWNLOAD_STOP,
+			aria2go.ON_DOWNLOAD_COMPLETE, aria2go.ON_DOWNLOAD_ERROR, aria2go.ON_BT_DOWNLOAD_COMPLETE,
+		}, nil
+	case "system.multicall":
+		return s.multicall(params)
+	}
+
+	handler, ok := methods[method]
+	if !ok {
+		return nil, &aria2go.ResponseError{Code: 1, Message: fmt.Sprintf("No such method: %s", method)}
+	}
+	params, err := s.checkToken(params)
+	if err != nil {
+		return nil, err
+	}
+	result, callErr := handler(s, args(params))
+	if callErr != nil {
+		return nil, &aria2go.ResponseError{Code: 1, Message: callErr.Error()}
+	}
+	return result, nil
+}
+
+// checkToken 校验并去掉 params 中的 token
+func (s *Server) checkToken(params []interface{}) ([]interface{}, *aria2go.ResponseError) {
+	if len(params) > 0 {
+		if token, ok := params[0].(string); ok && len(token) >= 6 && token[:6] == "token:" {
+			if s.Token != "" && token[6:] != s.Token {
+				return nil, &aria2go.ResponseError{Code: 1, Message: "Unauthorized"}
+			}
+			return params[1:], nil
+		}
+	}
+	if s.Token != "" {
+		return nil, &aria2go.ResponseError{Code: 1, Message: "Unauthorized"}
+	}
+	return params, nil
+}
+
+// multicall 依次调用每个方法,成功返回只有一个元素的数组,失败返回错误结构
+// 与 aria2 相同,整个 multicall 在持有 mu 时执行,其他请求不会插入到中间
+func (s *Server) multicall(params []interface{}) (interface{}, *aria2go.ResponseError) {
+	if len(params) != 1 {
+		return nil, &aria2go.ResponseError{Code: 1, Message: "system.multicall expected 1 parameter"}
+	}
+	calls, ok := params[0].([]interface{})
+	if !ok {
+		return nil, &aria2go.ResponseError{Code: 1, Message: "system.multicall expected array"}
+	}
+
+	results := make([]interface{}, 0, len(calls))
+	for _, item := range calls {
+		call, ok := item.(map[string]interface{})
+		if !ok {
+			results = append(results, &aria2go.ResponseError{Code: 1, Message: "system.multicall expected struct"})
+			continue
+		}
+		method, _ := call["methodName"].(string)
+		callParams, _ := call["params"].([]interface{})
+		if method == "system.multicall" {
+			results = append(results, &aria2go.ResponseError{Code: 1, Message: "Recursive system.multicall forbidden."})
+			continue
+		}
+
+		result, err := s.callLocked(method, callParams)
+		if err != nil {
+			results = append(results, err)
+			continue
+		}
+		results = append(results, []interface{}{result})
+	}
+	return results, nil
+}
+
+// notifyLocked 记录通知,在请求处理完成后发送
+func (s *Server) notifyLocked(method aria2go.NotificationType, gid string) {
+	s.pending = append(s.pending, notification{method: method, gid: gid})
+}
+
+// flush 向所有 websocket 连接发送等待中的通知
+func (s *Server) flush() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	conns := make([]*wsConn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+
+	for _, item := range pending {
+		data, _ := json.Marshal(map[string]interface{}{
+			"jsonrpc": aria2go.DEFAULT_JSONRPC_VERSION,
+			"method":  item.method,
+			"params":  []map[string]string{{"gid": item.gid}},
+		})
+		for _, conn := range conns {
+			_ = conn.write(data)
+		}
+	}
+}
+
+// Notify 向所有 websocket 连接发送通知,不改变任务状态
+func (s *Server) Notify(method aria2go.NotificationType, gid string) {
+	s.mu.Lock()
+	s.notifyLocked(method, gid)
+	s.mu.Unlock()
+	s.flush()
+}
+
+// newGidLocked 生成新的 gid
+func (s *Server) newGidLocked() string {
+	for {
+		s.nextGid++
+		gid := fmt.Sprintf("%016x", s.nextGid)
+		if _, ok := s.tasks[gid]; !ok {
+			return gid
+		}
+	}
+}
+
+func (s *Server) maxConcurrentLocked() int {
+	n, err := strconv.Atoi(s.global["max-concurrent-downloads"])
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
@@ -0,0 +1,209 @@
+package aria2test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	aria2go "github.com/gldsly/aria2-go"
+)
+
+func TestServerQueue(t *testing.T) {
+	server := NewServer("thanks")
+	defer server.Close()
+	client := server.Client()
+
+	if err := client.ChangeGlobalOption(nil, map[string]string{"max-concurrent-downloads": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	gids := make([]string, 0)
+	for _, uri := range []string{"http://a/1.iso", "http://a/2.iso", "http://a/3.iso"} {
+		gid, err := client.Download(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gids = append(gids, gid)
+	}
+
+	active, err := client.QueryDownloadingTaskFields(aria2go.FIELD_GID)
+	if err != nil || len(active) != 1 || active[0].Gid != gids[0] {
+		t.Fatalf("unexpected active tasks %v %v", active, err)
+	}
+	if err := client.Reorder([]string{gids[2], gids[1]}); err != nil {
+		t.Fatal(err)
+	}
+	waiting, err := client.WaitingGids()
+	if err != nil || !reflect.DeepEqual(waiting, []string{gids[2], gids[1]}) {
+		t.Fatalf("unexpected waiting %v %v", waiting, err)
+	}
+
+	if err := client.Pause(gids[0]); err != nil {
+		t.Fatal(err)
+	}
+	status, err := client.QueryTaskStatus(gids[2])
+	if err != nil || status.Status != string(aria2go.STATUS_ACTIVE) {
+		t.Fatalf("paused task should start next waiting task %v %v", status, err)
+	}
+
+	if err := server.Fail(gids[2], 3, "Resource not found"); err != nil {
+		t.Fatal(err)
+	}
+	stopped, err := client.QueryStoppedTask(0, 10)
+	if err != nil || len(stopped) != 1 || stopped[0].ErrorCode != "3" {
+		t.Fatalf("unexpected stopped %v %v", stopped, err)
+	}
+
+	stat, err := client.GetGlobalStat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := stat.Stats(); stats.NumActive != 1 || stats.NumWaiting != 1 || stats.NumStopped != 1 {
+		t.Errorf("unexpected global stat %#v", stats)
+	}
+
+	if _, err := client.QueryTaskStatus("0000000000000000"); !errors.Is(err, aria2go.ErrGIDNotFound) {
+		t.Errorf("want gid not found got %v", err)
+	}
+	if _, err := server.Client(aria2go.ClientSetTimeout(time.Second)).GetVersion(); err != nil {
+		t.Error(err)
+	}
+	if _, err := aria2go.NewAria2Client("wrong", aria2go.ClientSetAddr(client.Addr), aria2go.ClientSetPort(client.Port)).GetVersion(); !errors.Is(err, aria2go.ErrUnauthorized) {
+		t.Errorf("want unauthorized got %v", err)
+	}
+}
+
+func TestServerPauseAll(t *testing.T) {
+	server := NewServer("thanks")
+	defer server.Close()
+	client := server.Client()
+
+	if err := client.ChangeGlobalOption(nil, map[string]string{"max-concurrent-downloads": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	gids := make([]string, 0)
+	for _, uri := range []string{"http://a/1.iso", "http://a/2.iso", "http://a/3.iso"} {
+		gid, err := client.Download(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gids = append(gids, gid)
+	}
+
+	// 正在下载的任务保持原来的顺序排在等待队列前面
+	if err := client.PauseAll(""); err != nil {
+		t.Fatal(err)
+	}
+	waiting, err := client.WaitingGids()
+	if err != nil || !reflect.DeepEqual(waiting, gids) {
+		t.Fatalf("unexpected waiting %v %v", waiting, err)
+	}
+}
+
+func TestServerOptionsAndErrors(t *testing.T) {
+	server := NewServer("thanks")
+	defer server.Close()
+	client := server.Client()
+
+	gid, err := client.DownloadUris([]string{"http://a/1.iso"}, &aria2go.Option{Dir: "/data", Split: "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ChangeOption(gid, &aria2go.Option{MaxDownloadLimit: "1M"}); err != nil {
+		t.Fatal(err)
+	}
+	options, err := client.GetOption(gid)
+	if err != nil || options.Get("dir") != "/data" || options.Get("max-download-limit") != "1M" {
+		t.Fatalf("unexpected options %v %v", options, err)
+	}
+
+	// 与 aria2 相同,不能在 scope 中使用的参数被忽略
+	request := aria2go.NewRequestWithToken(client.Token).ChangeOption(gid, &aria2go.Option{MaxDownloadLimit: "2M"})
+	request.Params[len(request.Params)-1].(map[string]interface{})["max-concurrent-downloads"] = "5"
+	if _, _, err := aria2go.Call[string](client, request); err != nil {
+		t.Fatal(err)
+	}
+	options, err = client.GetOption(gid)
+	if err != nil || options.Get("max-download-limit") != "2M" || options.Get("max-concurrent-downloads") != "" {
+		t.Fatalf("unexpected options %v %v", options, err)
+	}
+
+	server.FailNext("aria2.tellStatus", 1, "injected")
+	if _, err := client.QueryTaskStatus(gid); err == nil || !strings.Contains(err.Error(), "injected") {
+		t.Errorf("want injected error got %v", err)
+	}
+	if _, err := client.QueryTaskStatus(gid); err != nil {
+		t.Errorf("fault should only fail once got %v", err)
+	}
+
+	server.FailNextHTTP(502)
+	if _, err := client.QueryTaskStatus(gid); !errors.Is(err, aria2go.ErrTransport) {
+		t.Errorf("want transport error got %v", err)
+	}
+
+	batch := client.NewMultiCallBatch()
+	status := aria2go.AddMultiCall[*aria2go.TaskStatusData](batch, aria2go.NewRequestWithToken(client.Token).TellStatus(gid, "gid"))
+	missing := aria2go.AddMultiCall[*aria2go.TaskStatusData](batch, aria2go.NewRequestWithToken(client.Token).TellStatus("0000000000000000"))
+	if err := batch.Send(); err != nil {
+		t.Fatal(err)
+	}
+	if status.Err != nil || status.Result.Gid != gid || !errors.Is(missing.Err, aria2go.ErrGIDNotFound) {
+		t.Errorf("unexpected multicall results %v %v", status, missing)
+	}
+}
+
+func TestServerProgressAndNotifications(t *testing.T) {
+	server := NewServer("thanks")
+	defer server.Close()
+	client := server.Client(aria2go.ClientUseWebsocket())
+	defer client.Close()
+
+	gid, err := client.Download("magnet:?xt=urn:btih:248d0a1cd08284299de78d5c1ed359bb46717d8c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan *aria2go.TaskStatusData, 1)
+	go func() {
+		status, err := client.WaitContext(ctx, gid)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- status
+	}()
+
+	child, err := server.CompleteWithFollowedBy(gid, "ubuntu.iso")
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates, err := client.WatchProgress(ctx, time.Millisecond, child)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetTotalLength(child, 300); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetProgress(child, 0, 100); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		server.Tick()
+	}
+
+	var last *aria2go.ProgressUpdate
+	for update := range updates {
+		last = update
+	}
+	if last == nil || !last.Done() || last.Percent != 100 {
+		t.Errorf("unexpected last progress %#v", last)
+	}
+
+	status := <-done
+	if status == nil || status.Gid != child || status.BitTorrent == nil || status.BitTorrent.Info.Name != "ubuntu.iso" {
+		t.Errorf("unexpected wait result %#v", status)
+	}
+}
@@ -0,0 +1,238 @@
+package aria2test
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	aria2go "github.com/gldsly/aria2-go"
+)
+
+// task 模拟的下载任务
+type task struct {
+	gid             string
+	status          aria2go.TaskStatus
+	uris            []string
+	name            string
+	totalLength     int64
+	completedLength int64
+	uploadLength    int64
+	downloadSpeed   int64
+	uploadSpeed     int64
+	options         map[string]string
+	errorCode       int
+	errorMessage    string
+	followedBy      []string
+	following       string
+	torrent         bool
+}
+
+// dir 任务保存目录,未设置时使用全局参数
+func (t *task) dir(global map[string]string) string {
+	if dir := t.options["dir"]; dir != "" {
+		return dir
+	}
+	return global["dir"]
+}
+
+// fileName 任务文件名,优先使用 out 参数
+func (t *task) fileName() string {
+	if out := t.options["out"]; out != "" {
+		return out
+	}
+	if t.name != "" {
+		return t.name
+	}
+	if len(t.uris) > 0 {
+		if name := path.Base(strings.SplitN(t.uris[0], "?", 2)[0]); name != "" && name != "/" && name != "." {
+			return name
+		}
+	}
+	return "index.html"
+}
+
+func (t *task) files(global map[string]string) []map[string]interface{} {
+	uris := make([]map[string]string, 0, len(t.uris))
+	for _, uri := range t.uris {
+		status := "waiting"
+		if t.status == aria2go.STATUS_ACTIVE {
+			status = "used"
+		}
+		uris = append(uris, map[string]string{"uri": uri, "status": status})
+	}
+	return []map[string]interface{}{{
+		"index":           "1",
+		"path":            path.Join(t.dir(global), t.fileName()),
+		"length":          strconv.FormatInt(t.totalLength, 10),
+		"completedLength": strconv.FormatInt(t.completedLength, 10),
+		"selected":        "true",
+		"uris":            uris,
+	}}
+}
+
+// statusMap 转换为 tellStatus 的返回结构,keys 为空时返回所有字段
+func (t *task) statusMap(global map[string]string, keys []string) map[string]interface{} {
+	pieceLength := int64(1024 * 1024)
+	numPieces := (t.totalLength + pieceLength - 1) / pieceLength
+	result := map[string]interface{}{
+		"gid":             t.gid,
+		"status":          string(t.status),
+		"totalLength":     strconv.FormatInt(t.totalLength, 10),
+		"completedLength": strconv.FormatInt(t.completedLength, 10),
+		"uploadLength":    strconv.FormatInt(t.uploadLength, 10),
+		"downloadSpeed":   strconv.FormatInt(t.downloadSpeed, 10),
+		"uploadSpeed":     strconv.FormatInt(t.uploadSpeed, 10),
+		"pieceLength":     strconv.FormatInt(pieceLength, 10),
+		"numPieces":       strconv.FormatInt(numPieces, 10),
+		"connections":     "0",
+		"dir":             t.dir(global),
+		"files":           t.files(global),
+		"bitfield":        bitfield(numPieces, t.completedLength/pieceLength),
+	}
+	if t.status == aria2go.STATUS_ACTIVE {
+		result["connections"] = "1"
+	}
+	if t.errorCode != 0 || t.status == aria2go.STATUS_ERROR || t.status == aria2go.STATUS_COMPLETE {
+		result["errorCode"] = strconv.Itoa(t.errorCode)
+		result["errorMessage"] = t.errorMessage
+	}
+	if len(t.followedBy) > 0 {
+		result["followedBy"] = t.followedBy
+	}
+	if t.following != "" {
+		result["following"] = t.following
+	}
+	if t.torrent {
+		result["infoHash"] = fmt.Sprintf("%040s", t.gid)
+		result["numSeeders"] = "0"
+		result["seeder"] = strconv.FormatBool(t.totalLength > 0 && t.completedLength >= t.totalLength)
+		result["bittorrent"] = map[string]interface{}{
+			"mode": "single",
+			"info": map[string]string{"name": t.fileName()},
+		}
+	}
+
+	if len(keys) == 0 {
+		return result
+	}
+	filtered := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if value, ok := result[key]; ok {
+			filtered[key] = value
+		}
+	}
+	return filtered
+}
+
+// bitfield 生成前 completed 个分片已下载的十六进制 bitfield
+func bitfield(numPieces, completed int64) string {
+	bits := make([]byte, (numPieces+7)/8)
+	for i := int64(0); i < completed && i < numPieces; i++ {
+		bits[i/8] |= 0x80 >> (i % 8)
+	}
+	return fmt.Sprintf("%x", bits)
+}
+
+// removeGid 从列表中删除 gid,返回删除前的位置
+func removeGid(list []string, gid string) ([]string, int) {
+	for i, item := range list {
+		if item == gid {
+			return append(list[:i:i], list[i+1:]...), i
+		}
+	}
+	return list, -1
+}
+
+// insertGid 在 pos 位置插入 gid,pos 超出范围时插入到末尾
+func insertGid(list []string, gid string, pos int) []string {
+	if pos < 0 || pos > len(list) {
+		pos = len(list)
+	}
+	result := make([]string, 0, len(list)+1)
+	result = append(result, list[:pos]...)
+	result = append(result, gid)
+	return append(result, list[pos:]...)
+}
+
+// addTaskLocked 添加任务到等待队列,position 小于 0 时添加到末尾
+func (s *Server) addTaskLocked(t *task, position int) {
+	t.status = aria2go.STATUS_WAITING
+	if t.options["pause"] == "true" {
+		t.status = aria2go.STATUS_PAUSED
+	}
+	delete(t.options, "pause")
+	s.tasks[t.gid] = t
+	s.waiting = insertGid(s.waiting, t.gid, position)
+	s.scheduleLocked()
+}
+
+// scheduleLocked 将等待中的任务移动到下载队列,直到达到 max-concurrent-downloads
+func (s *Server) scheduleLocked() {
+	for len(s.active) < s.maxConcurrentLocked() {
+		next := -1
+		for i, gid := range s.waiting {
+			if s.tasks[gid].status == aria2go.STATUS_WAITING {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return
+		}
+		gid := s.waiting[next]
+		s.waiting, _ = removeGid(s.waiting, gid)
+		s.active = append(s.active, gid)
+		s.tasks[gid].status = aria2go.STATUS_ACTIVE
+		s.notifyLocked(aria2go.ON_DOWNLOAD_START, gid)
+	}
+}
+
+// stopLocked 结束任务并移动到已停止队列
+func (s *Server) stopLocked(t *task, status aria2go.TaskStatus) {
+	s.active, _ = removeGid(s.active, t.gid)
+	s.waiting, _ = removeGid(s.waiting, t.gid)
+	t.status = status
+	t.downloadSpeed = 0
+	t.uploadSpeed = 0
+	s.stopped = append(s.stopped, t.gid)
+	s.numStoppedTotal++
+
+	switch status {
+	case aria2go.STATUS_COMPLETE:
+		if t.torrent {
+			s.notifyLocked(aria2go.ON_BT_DOWNLOAD_COMPLETE, t.gid)
+		}
+		s.notifyLocked(aria2go.ON_DOWNLOAD_COMPLETE, t.gid)
+	case aria2go.STATUS_ERROR:
+		s.notifyLocked(aria2go.ON_DOWNLOAD_ERROR, t.gid)
+	default:
+		s.notifyLocked(aria2go.ON_DOWNLOAD_STOP, t.gid)
+	}
+	s.scheduleLocked()
+}
+
+// pauseLocked 暂停任务,正在下载的任务移动到等待队列的 pos 位置
+func (s *Server) pauseLocked(t *task, pos int) {
+	switch t.status {
+	case aria2go.STATUS_ACTIVE:
+		s.active, _ = removeGid(s.active, t.gid)
+		s.waiting = insertGid(s.waiting, t.gid, pos)
+	case aria2go.STATUS_WAITING:
+	default:
+		return
+	}
+	t.status = aria2go.STATUS_PAUSED
+	t.downloadSpeed = 0
+	t.uploadSpeed = 0
+	s.notifyLocked(aria2go.ON_DOWNLOAD_PAUSE, t.gid)
+}
+
+// findLocked 查找任务,不存在时返回 aria2 的错误信息
+func (s *Server) findLocked(gid string) (*task, error) {
+	t, ok := s.tasks[gid]
+	if !ok {
+		return nil, fmt.Errorf("GID %s is not found", gid)
+	}
+	return t, nil
+}
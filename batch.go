@@ -0,0 +1,148 @@
+package aria2go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// RequestBatch 通过 jsonrpc batch 一次发送多个请求
+// 与 MultiCallBatch 不同,每个请求都有自己的 id 和 token,响应按 id 匹配,与返回顺序无关
+//
+//	batch := client.NewRequestBatch()
+//	status := aria2go.AddBatchCall[*aria2go.TaskStatusData](batch, aria2go.NewRequestWithToken(client.Token).TellStatus(gid))
+//	stat := aria2go.AddBatchCall[*aria2go.GlobalStatData](batch, aria2go.NewRequestWithToken(client.Token).GetGlobalStat())
+//	if err := batch.Send(); err != nil {
+//		return err
+//	}
+//	fmt.Println(status.Result, status.Err, stat.Result, stat.Err)
+type RequestBatch struct {
+	client *Aria2Client
+	items  []batchItem
+}
+
+// BatchResult RequestBatch 中单个请求的结果
+// 调用 RequestBatch.Send 之后才有数据
+type BatchResult[T any] struct {
+	Result T
+	// Err 为该请求的错误,aria2 返回的错误为 *RPCError
+	Err error
+	// ReplayID 该请求的 id
+	ReplayID string
+}
+
+// batchItem RequestBatch 中的单个请求
+type batchItem interface {
+	request() *RequestBody
+	decode(data json.RawMessage)
+	fail(err error)
+}
+
+type batchResultItem[T any] struct {
+	req    *RequestBody
+	result *BatchResult[T]
+}
+
+func (b *batchResultItem[T]) request() *RequestBody {
+	return b.req
+}
+
+func (b *batchResultItem[T]) decode(data json.RawMessage) {
+	*b.result = BatchResult[T]{ReplayID: b.req.ReplayID}
+	resp := &callResponse[T]{}
+	if err := decodeResponse(b.req.Method, data, resp); err != nil {
+		b.fail(err)
+		return
+	}
+	b.result.Result = resp.Result
+}
+
+func (b *batchResultItem[T]) fail(err error) {
+	b.result.Err = err
+	b.result.ReplayID = b.req.ReplayID
+}
+
+// NewRequestBatch 创建 jsonrpc batch 请求
+func (a Aria2Client) NewRequestBatch() *RequestBatch {
+	return &RequestBatch{client: &a}
+}
+
+// AddBatchCall 添加请求到 batch 中,返回的结果在 batch 发送后可用
+// request 需要设置 token
+func AddBatchCall[T any](batch *RequestBatch, request *RequestBody) *BatchResult[T] {
+	result := &BatchResult[T]{}
+	batch.items = append(batch.items, &batchResultItem[T]{req: request, result: result})
+	return result
+}
+
+// Len batch 中的请求数量
+func (b *RequestBatch) Len() int {
+	return len(b.items)
+}
+
+// Send 发送 batch 中的所有请求
+func (b *RequestBatch) Send() error {
+	return b.SendContext(context.Background())
+}
+
+// SendContext 同 Send,通过 ctx 控制请求的取消和超时
+// 只有整个请求失败时才返回错误,同时所有 BatchResult 的 Err 都会设置为该错误
+// 单个请求的错误只记录在对应的 BatchResult 中
+func (b *RequestBatch) SendContext(ctx context.Context) (err error) {
+	defer func() {
+		if err != nil {
+			for _, item := range b.items {
+				item.fail(err)
+			}
+		}
+	}()
+
+	requests := make([]*RequestBody, 0, len(b.items))
+	for _, item := range b.items {
+		requests = append(requests, item.request())
+	}
+	body, _, err := CreateBatch(requests...)
+	if err != nil {
+		return err
+	}
+
+	data, err := b.client.SendRequestContext(ctx, body)
+	if err != nil {
+		return err
+	}
+
+	// 整个请求无法解析时 aria2 返回单个错误响应
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		if err := decodeResponse("", data, &BasicModel{}); err != nil {
+			return err
+		}
+		return &DecodeError{Err: fmt.Errorf("batch response is not an array")}
+	}
+
+	responses := make([]json.RawMessage, 0, len(b.items))
+	if err := json.Unmarshal(data, &responses); err != nil {
+		return &DecodeError{Err: err}
+	}
+
+	responseMap := make(map[string]json.RawMessage, len(responses))
+	for _, item := range responses {
+		basic := &BasicModel{}
+		if err := json.Unmarshal(item, basic); err != nil {
+			return &DecodeError{Err: err}
+		}
+		responseMap[basic.ID] = item
+	}
+
+	for _, item := range b.items {
+		replayID := item.request().ReplayID
+		resp, ok := responseMap[replayID]
+		if !ok {
+			item.fail(&DecodeError{Err: fmt.Errorf("batch response of %s is missing", replayID)})
+			continue
+		}
+		item.decode(resp)
+	}
+	return nil
+}
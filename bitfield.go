@@ -0,0 +1,172 @@
+package aria2go
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// BitField aria2 返回的 bitfield 解析结果
+// aria2 以十六进制字符串表示已下载的分片,最高位表示第 0 个分片
+type BitField struct {
+	bits        []byte
+	numPieces   int
+	pieceLength int64
+	totalLength int64
+}
+
+// ByteRange 字节范围 [Start, End)
+type ByteRange struct {
+	Start int64
+	End   int64
+}
+
+// ParseBitField 解析 aria2 十六进制格式的 bitfield
+// numPieces 为 0 时按 bitfield 长度计算分片数量
+// pieceLength totalLength 用于计算字节范围,只需要分片信息时可以为 0
+// pieceLength 为 0 时 CompletedRanges FileCompletion 返回 ErrPieceLengthRequired
+func ParseBitField(bitfield string, numPieces int, pieceLength, totalLength int64) (*BitField, error) {
+	bits, err := hex.DecodeString(bitfield)
+	if err != nil {
+		return nil, &DecodeError{Err: fmt.Errorf("parse bitfield: %w", err)}
+	}
+	if numPieces <= 0 {
+		numPieces = len(bits) * 8
+	}
+	if len(bits) < (numPieces+7)/8 {
+		return nil, &DecodeError{Err: fmt.Errorf("bitfield length %d is too short for %d pieces", len(bits), numPieces)}
+	}
+
+	return &BitField{
+		bits:        bits,
+		numPieces:   numPieces,
+		pieceLength: pieceLength,
+		totalLength: totalLength,
+	}, nil
+}
+
+// DecodeBitField 解析任务的 bitfield
+func (t *TaskStatusData) DecodeBitField() (*BitField, error) {
+	stats, err := t.Stats()
+	if err != nil {
+		return nil, err
+	}
+	return ParseBitField(t.BitField, int(stats.NumPieces), stats.PieceLength, stats.TotalLength)
+}
+
+// DecodeBitField 解析 peer 的 bitfield
+// peer 数据中没有分片信息,需要传入对应任务的数据
+func (p *PeerInfo) DecodeBitField(task *TaskStatusData) (*BitField, error) {
+	stats, err := task.Stats()
+	if err != nil {
+		return nil, err
+	}
+	return ParseBitField(p.BitField, int(stats.NumPieces), stats.PieceLength, stats.TotalLength)
+}
+
+// NumPieces 分片数量
+func (b *BitField) NumPieces() int {
+	return b.numPieces
+}
+
+// HasPiece 第 index 个分片是否已下载,index 从 0 开始
+func (b *BitField) HasPiece(index int) bool {
+	if index < 0 || index >= b.numPieces {
+		return false
+	}
+	return b.bits[index/8]&(0x80>>(index%8)) != 0
+}
+
+// CompletedPieces 已下载的分片数量
+func (b *BitField) CompletedPieces() int {
+	count := 0
+	for i := 0; i < b.numPieces; i++ {
+		if b.HasPiece(i) {
+			count++
+		}
+	}
+	return count
+}
+
+// pieceRange 第 index 个分片的字节范围,最后一个分片以 totalLength 为结尾
+func (b *BitField) pieceRange(index int) ByteRange {
+	start := int64(index) * b.pieceLength
+	end := start + b.pieceLength
+	if b.totalLength > 0 && end > b.totalLength {
+		end = b.totalLength
+	}
+	return ByteRange{Start: start, End: end}
+}
+
+// CompletedRanges 已下载的连续字节范围
+// 需要解析时传入 pieceLength,否则返回 ErrPieceLengthRequired
+func (b *BitField) CompletedRanges() ([]ByteRange, error) {
+	if b.pieceLength <= 0 {
+		return nil, ErrPieceLengthRequired
+	}
+	ranges := make([]ByteRange, 0)
+	for i := 0; i < b.numPieces; i++ {
+		if !b.HasPiece(i) {
+			continue
+		}
+		piece := b.pieceRange(i)
+		if last := len(ranges) - 1; last >= 0 && ranges[last].End == piece.Start {
+			ranges[last].End = piece.End
+			continue
+		}
+		ranges = append(ranges, piece)
+	}
+	return ranges, nil
+}
+
+// FileCompletion 按文件计算已下载的字节数
+// files 需要是任务的完整文件列表,文件按顺序连续排列在分片中
+// 需要解析时传入 pieceLength,否则返回 ErrPieceLengthRequired
+func (b *BitField) FileCompletion(files []*TaskStatusDataFile) (completed []int64, err error) {
+	ranges, err := b.CompletedRanges()
+	if err != nil {
+		return nil, err
+	}
+	completed = make([]int64, 0, len(files))
+
+	offset := int64(0)
+	for _, file := range files {
+		length, err := strconv.ParseInt(file.Length, 10, 64)
+		if err != nil {
+			return nil, &DecodeError{Err: fmt.Errorf("parse file length: %w", err)}
+		}
+
+		fileEnd := offset + length
+		done := int64(0)
+		for _, item := range ranges {
+			start, end := item.Start, item.End
+			if start < offset {
+				start = offset
+			}
+			if end > fileEnd {
+				end = fileEnd
+			}
+			if end > start {
+				done += end - start
+			}
+		}
+
+		completed = append(completed, done)
+		offset = fileEnd
+	}
+	return completed, nil
+}
+
+// PieceAvailability 计算每个分片在所有 bitfield 中出现的次数
+// 传入所有 peer 的 bitfield 可以得到整个 swarm 中每个分片的可用数量
+func PieceAvailability(numPieces int, bitfields ...*BitField) []int {
+	availability := make([]int, numPieces)
+	for _, bitfield := range bitfields {
+		for i := 0; i < numPieces; i++ {
+			if bitfield.HasPiece(i) {
+				availability[i]++
+			}
+		}
+	}
+	return availability
+}
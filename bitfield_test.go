@@ -0,0 +1,67 @@
+package aria2go
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBitField(t *testing.T) {
+	// 10 个分片,每个分片 100 字节,最后一个分片 50 字节
+	task := &TaskStatusData{
+		BitField:    "e3c0",
+		NumPieces:   "10",
+		PieceLength: "100",
+		TotalLength: "950",
+		Files: []*TaskStatusDataFile{
+			{Length: "250"},
+			{Length: "700"},
+		},
+	}
+	bitfield, err := task.DecodeBitField()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bitfield.HasPiece(0) || bitfield.HasPiece(3) || !bitfield.HasPiece(9) || bitfield.CompletedPieces() != 7 {
+		t.Errorf("unexpected pieces %d", bitfield.CompletedPieces())
+	}
+
+	ranges, err := bitfield.CompletedRanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ByteRange{{0, 300}, {600, 950}}
+	if len(ranges) != len(want) || ranges[0] != want[0] || ranges[1] != want[1] {
+		t.Errorf("unexpected ranges %v", ranges)
+	}
+
+	completed, err := bitfield.FileCompletion(task.Files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed[0] != 250 || completed[1] != 400 {
+		t.Errorf("unexpected file completion %v", completed)
+	}
+
+	peer := &PeerInfo{BitField: "ffc0"}
+	peerBitfield, err := peer.DecodeBitField(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	availability := PieceAvailability(bitfield.NumPieces(), bitfield, peerBitfield)
+	if availability[0] != 2 || availability[3] != 1 {
+		t.Errorf("unexpected availability %v", availability)
+	}
+
+	// 没有分片大小时只能查询分片信息
+	pieces, err := ParseBitField("e3c0", 10, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pieces.CompletedRanges(); !errors.Is(err, ErrPieceLengthRequired) {
+		t.Errorf("want piece length error got %v", err)
+	}
+	if _, err := pieces.FileCompletion(task.Files); !errors.Is(err, ErrPieceLengthRequired) {
+		t.Errorf("want piece length error got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package aria2go
+
+import "context"
+
+// callResponse Call 使用的通用响应结构
+type callResponse[T any] struct {
+	BasicModel
+	Result T `json:"result"`
+}
+
+// Call 发送请求并将响应中的 result 解析为 T
+// request 为任意请求构造的结果,不需要调用 Create
+//
+//	stat, replayID, err := aria2go.Call[*aria2go.GlobalStatData](client, aria2go.NewRequestWithToken(client.Token).GetGlobalStat())
+func Call[T any](client *Aria2Client, request *RequestBody) (result T, replayID string, err error) {
+	return CallContext[T](context.Background(), client, request)
+}
+
+// CallContext 同 Call,通过 ctx 控制请求的取消和超时
+func CallContext[T any](ctx context.Context, client *Aria2Client, request *RequestBody) (result T, replayID string, err error) {
+	body, replayID, err := request.Create()
+	if err != nil {
+		return result, "", err
+	}
+
+	data, err := client.SendRequestContext(ctx, body)
+	if err != nil {
+		return result, replayID, err
+	}
+
+	resp := &callResponse[T]{}
+	if err := decodeResponse(request.Method, data, resp); err != nil {
+		return result, replayID, err
+	}
+	return resp.Result, replayID, nil
+}
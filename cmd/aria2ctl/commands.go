@@ -0,0 +1,456 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	aria2go "github.com/gldsly/aria2-go"
+)
+
+// keyValues 可以重复设置的 KEY=VALUE 参数
+type keyValues [][2]string
+
+func (k *keyValues) String() string {
+	return fmt.Sprint(*k)
+}
+
+func (k *keyValues) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("expected KEY=VALUE, got %q", value)
+	}
+	*k = append(*k, [2]string{key, val})
+	return nil
+}
+
+// apply 设置到 builder,同一个参数出现多次时作为多个值添加
+func (k keyValues) apply(builder *aria2go.OptionBuilder) {
+	seen := make(map[string]bool, len(k))
+	for _, item := range k {
+		if seen[item[0]] {
+			builder.Add(item[0], item[1])
+		} else {
+			builder.Set(item[0], item[1])
+		}
+		seen[item[0]] = true
+	}
+}
+
+// parseKeyValues 解析 KEY=VALUE 形式的参数
+func parseKeyValues(args []string) (keyValues, error) {
+	values := keyValues{}
+	for _, arg := range args {
+		if err := values.Set(arg); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// requireArgs 检查参数数量
+func requireArgs(args []string, min int, usage string) error {
+	if len(args) < min {
+		return fmt.Errorf("usage: aria2ctl %s", usage)
+	}
+	return nil
+}
+
+func runAdd(a *app, args []string) error {
+	flags := a.newFlagSet("add")
+	torrent := flags.String("torrent", "", "add a local .torrent file, args are web-seed uris")
+	metalink := flags.String("metalink", "", "add a local metalink file")
+	dir := flags.String("dir", "", "directory to store the download")
+	out := flags.String("out", "", "file name of the download")
+	position := flags.Int("position", -1, "position in the waiting queue, default is the end")
+	pause := flags.Bool("pause", false, "add the download paused")
+	options := keyValues{}
+	flags.Var(&options, "option", "aria2 option KEY=VALUE, can be repeated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	builder := aria2go.NewOptionBuilder()
+	if *dir != "" {
+		builder.Dir(*dir)
+	}
+	if *out != "" {
+		builder.Out(*out)
+	}
+	if *pause {
+		builder.Pause(true)
+	}
+	options.apply(builder)
+	option, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	var pos []int
+	if *position >= 0 {
+		pos = append(pos, *position)
+	}
+
+	switch {
+	case *torrent != "" && *metalink != "":
+		return errors.New("-torrent and -metalink cannot be used together")
+	case *torrent != "":
+		data, err := os.ReadFile(*torrent)
+		if err != nil {
+			return err
+		}
+		gid, err := a.client.DownloadWithTorrentDataContext(a.ctx, data, flags.Args(), option, pos...)
+		if err != nil {
+			return err
+		}
+		return a.printGids(gid)
+	case *metalink != "":
+		file, err := os.Open(*metalink)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		gids, err := a.client.DownloadWithMetalinkContext(a.ctx, file, option, pos...)
+		if err != nil {
+			return err
+		}
+		return a.printGids(gids...)
+	}
+
+	if err := requireArgs(flags.Args(), 1, commands["add"].usage); err != nil {
+		return err
+	}
+	gid, err := a.client.DownloadUrisContext(a.ctx, flags.Args(), option, pos...)
+	if err != nil {
+		return err
+	}
+	return a.printGids(gid)
+}
+
+func runStatus(a *app, args []string) error {
+	if err := requireArgs(args, 1, commands["status"].usage); err != nil {
+		return err
+	}
+	return a.repeat(func() error {
+		tasks := make([]*aria2go.TaskStatusData, 0, len(args))
+		for _, gid := range args {
+			task, err := a.client.QueryTaskStatusFieldsContext(a.ctx, gid, statusFields|aria2go.FIELD_DIR)
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, task)
+		}
+		return a.printTasks(tasks, true)
+	})
+}
+
+func runList(a *app, args []string) error {
+	state := "all"
+	if len(args) > 0 {
+		state = args[0]
+	}
+	switch state {
+	case "active", "waiting", "stopped", "all":
+	default:
+		return fmt.Errorf("usage: aria2ctl %s", commands["ls"].usage)
+	}
+
+	return a.repeat(func() error {
+		tasks := make([]*aria2go.TaskStatusData, 0)
+		if state == "active" || state == "all" {
+			active, err := a.client.QueryDownloadingTaskFieldsContext(a.ctx, listFields)
+			if err != nil {
+				return err
+			}
+			tasks = append(tasks, active...)
+		}
+		iterators := make([]*aria2go.TaskIterator, 0, 2)
+		if state == "waiting" || state == "all" {
+			iterators = append(iterators, a.client.IterWaitingContext(a.ctx, 0, aria2go.DEFAULT_PAGE_SIZE, listFields.Keys()...))
+		}
+		if state == "stopped" || state == "all" {
+			iterators = append(iterators, a.client.IterStoppedContext(a.ctx, 0, aria2go.DEFAULT_PAGE_SIZE, listFields.Keys()...))
+		}
+		for _, iter := range iterators {
+			for iter.Next() {
+				tasks = append(tasks, iter.Task())
+			}
+			if err := iter.Err(); err != nil {
+				return err
+			}
+		}
+		return a.printTasks(tasks, false)
+	})
+}
+
+func runPause(a *app, args []string) error {
+	flags := a.newFlagSet("pause")
+	all := flags.Bool("all", false, "pause all downloads")
+	force := flags.Bool("force", false, "pause without contacting trackers")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *all {
+		var err error
+		if *force {
+			err = a.client.ForcePauseAllContext(a.ctx)
+		} else {
+			err = a.client.PauseAllContext(a.ctx, "")
+		}
+		if err != nil {
+			return err
+		}
+		return a.printResult("OK")
+	}
+
+	if err := requireArgs(flags.Args(), 1, commands["pause"].usage); err != nil {
+		return err
+	}
+	pause := a.client.PauseContext
+	if *force {
+		pause = a.client.ForcePauseContext
+	}
+	return a.eachGid(flags.Args(), pause)
+}
+
+func runResume(a *app, args []string) error {
+	flags := a.newFlagSet("resume")
+	all := flags.Bool("all", false, "resume all downloads")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *all {
+		if err := a.client.UnpauseAllContext(a.ctx, ""); err != nil {
+			return err
+		}
+		return a.printResult("OK")
+	}
+
+	if err := requireArgs(flags.Args(), 1, commands["resume"].usage); err != nil {
+		return err
+	}
+	return a.eachGid(flags.Args(), a.client.UnpauseContext)
+}
+
+func runRemove(a *app, args []string) error {
+	flags := a.newFlagSet("rm")
+	force := flags.Bool("force", false, "remove without contacting trackers")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := requireArgs(flags.Args(), 1, commands["rm"].usage); err != nil {
+		return err
+	}
+	remove := a.client.RemoveContext
+	if *force {
+		remove = a.client.ForceRemoveContext
+	}
+	return a.eachGid(flags.Args(), remove)
+}
+
+// eachGid 对每个 gid 执行 fn 并打印 gid 列表
+func (a *app) eachGid(gids []string, fn func(ctx context.Context, gid string) error) error {
+	for _, gid := range gids {
+		if err := fn(a.ctx, gid); err != nil {
+			return err
+		}
+	}
+	return a.printGids(gids...)
+}
+
+func runPurge(a *app, args []string) error {
+	if len(args) == 0 {
+		if err := a.client.RemoveAllTaskContext(a.ctx); err != nil {
+			return err
+		}
+		return a.printResult("OK")
+	}
+	return a.eachGid(args, a.client.RemoveTaskContext)
+}
+
+func runMove(a *app, args []string) error {
+	flags := a.newFlagSet("mv")
+	how := flags.String("how", "set", "position is relative to: set (the front), cur (current position) or end")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	args = flags.Args()
+	if err := requireArgs(args, 2, commands["mv"].usage); err != nil {
+		return err
+	}
+
+	gid := args[0]
+	var position int
+	var err error
+	switch args[1] {
+	case "front":
+		position, err = a.client.MoveToFrontContext(a.ctx, gid)
+	case "back":
+		position, err = a.client.MoveToBackContext(a.ctx, gid)
+	case "before", "after":
+		if err := requireArgs(args, 3, commands["mv"].usage); err != nil {
+			return err
+		}
+		if args[1] == "before" {
+			position, err = a.client.MoveBeforeContext(a.ctx, gid, args[2])
+		} else {
+			position, err = a.client.MoveAfterContext(a.ctx, gid, args[2])
+		}
+	default:
+		pos, parseErr := strconv.Atoi(args[1])
+		if parseErr != nil {
+			return fmt.Errorf("invalid position %q", args[1])
+		}
+		opt := aria2go.PositionOpt("POS_" + strings.ToUpper(*how))
+		if opt != aria2go.POS_SET && opt != aria2go.POS_CUR && opt != aria2go.POS_END {
+			return fmt.Errorf("invalid -how %q", *how)
+		}
+		position, err = a.client.ChangePositionContext(a.ctx, gid, pos, opt)
+	}
+	if err != nil {
+		return err
+	}
+	return a.print(map[string]interface{}{"gid": gid, "position": position}, func(w *tabwriter.Writer) error {
+		fmt.Fprintf(w, "%s\t%d\n", gid, position)
+		return nil
+	})
+}
+
+func runOptions(a *app, args []string) error {
+	if err := requireArgs(args, 1, commands["options"].usage); err != nil {
+		return err
+	}
+	action := args[0]
+	flags := a.newFlagSet("options")
+	global := flags.Bool("global", false, "use global options")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	args = flags.Args()
+
+	switch action {
+	case "get":
+		var options aria2go.OptionMap
+		var err error
+		if *global {
+			options, err = a.client.GetGlobalOptionContext(a.ctx)
+		} else {
+			if err := requireArgs(args, 1, commands["options"].usage); err != nil {
+				return err
+			}
+			options, err = a.client.GetOptionContext(a.ctx, args[0])
+		}
+		if err != nil {
+			return err
+		}
+		return a.printOptions(options)
+	case "set":
+		gid := ""
+		if !*global {
+			if err := requireArgs(args, 2, commands["options"].usage); err != nil {
+				return err
+			}
+			gid, args = args[0], args[1:]
+		}
+		values, err := parseKeyValues(args)
+		if err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("usage: aria2ctl %s", commands["options"].usage)
+		}
+		builder := aria2go.NewOptionBuilder()
+		values.apply(builder)
+		options, err := builder.BuildMap()
+		if err != nil {
+			return err
+		}
+		if *global {
+			if err := a.client.ChangeGlobalOptionContext(a.ctx, nil, options); err != nil {
+				return err
+			}
+			return a.printResult("OK")
+		}
+
+		if err := a.client.ChangeOptionContext(a.ctx, gid, nil, options); err != nil {
+			return err
+		}
+		restart := make([]string, 0)
+		for key := range options {
+			if aria2go.OptionRequiresRestart(key) {
+				restart = append(restart, key)
+			}
+		}
+		if len(restart) > 0 && a.format == "table" {
+			sort.Strings(restart)
+			fmt.Fprintf(a.errOut, "note: changing %s restarts the download if it is active\n", strings.Join(restart, ", "))
+		}
+		return a.printResult("OK")
+	}
+	return fmt.Errorf("usage: aria2ctl %s", commands["options"].usage)
+}
+
+func runStat(a *app, args []string) error {
+	return a.repeat(func() error {
+		stat, err := a.client.GetGlobalStatContext(a.ctx)
+		if err != nil {
+			return err
+		}
+		stats, err := stat.Stats()
+		if err != nil {
+			return err
+		}
+		return a.print(stats, func(w *tabwriter.Writer) error {
+			fmt.Fprintln(w, "DOWN\tUP\tACTIVE\tWAITING\tSTOPPED")
+			fmt.Fprintf(w, "%s/s\t%s/s\t%d\t%d\t%d\n", humanBytes(stats.DownloadSpeed), humanBytes(stats.UploadSpeed),
+				stats.NumActive, stats.NumWaiting, stats.NumStopped)
+			return nil
+		})
+	})
+}
+
+func runGlobal(a *app, args []string) error {
+	if len(args) != 1 || args[0] != "stat" {
+		return fmt.Errorf("usage: aria2ctl %s", commands["global"].usage)
+	}
+	return runStat(a, nil)
+}
+
+func runVersion(a *app, args []string) error {
+	version, err := a.client.GetVersionContext(a.ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(version, func(w *tabwriter.Writer) error {
+		fmt.Fprintf(w, "aria2 %s\n", version.Version)
+		fmt.Fprintf(w, "features: %s\n", strings.Join(version.EnabledFeatures, ", "))
+		return nil
+	})
+}
+
+func runSaveSession(a *app, args []string) error {
+	if err := a.client.SaveSessionContext(a.ctx); err != nil {
+		return err
+	}
+	return a.printResult("OK")
+}
+
+func runShutdown(a *app, args []string) error {
+	flags := a.newFlagSet("shutdown")
+	force := flags.Bool("force", false, "shutdown without contacting trackers")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *force {
+		if err := a.client.ForceShutdownContext(a.ctx); err != nil {
+			return err
+		}
+	} else if err := a.client.ShutdownContext(a.ctx); err != nil {
+		return err
+	}
+	return a.printResult("OK")
+}
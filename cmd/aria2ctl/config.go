@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	aria2go "github.com/gldsly/aria2-go"
+)
+
+// config 连接 aria2 的配置
+// 优先级从高到低为命令行参数 环境变量 配置文件 默认值,三者使用相同的名称
+type config struct {
+	Addr      string
+	Port      string
+	Token     string
+	RPCPath   string
+	Secure    bool
+	Websocket bool
+	Timeout   time.Duration
+}
+
+// configKeys 配置名称与环境变量的对应关系
+var configKeys = map[string]string{
+	"addr":      "ARIA2_ADDR",
+	"port":      "ARIA2_PORT",
+	"token":     "ARIA2_TOKEN",
+	"rpc-path":  "ARIA2_RPC_PATH",
+	"secure":    "ARIA2_SECURE",
+	"websocket": "ARIA2_WEBSOCKET",
+	"timeout":   "ARIA2_TIMEOUT",
+}
+
+func defaultConfig() *config {
+	return &config{
+		Addr:    aria2go.DEFAULT_ARIA2_ADDR,
+		Port:    aria2go.DEFAULT_ARIA2_PORT,
+		RPCPath: aria2go.DEFAULT_RPC_PATH,
+		Timeout: 30 * time.Second,
+	}
+}
+
+// defaultConfigPath 默认配置文件路径,例如 ~/.config/aria2ctl/config
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "aria2ctl", "config")
+}
+
+// set 设置配置项
+func (c *config) set(key, value string) error {
+	value = strings.TrimSpace(value)
+	switch key {
+	case "addr":
+		c.Addr = value
+	case "port":
+		c.Port = value
+	case "token":
+		c.Token = value
+	case "rpc-path":
+		c.RPCPath = value
+	case "secure", "websocket":
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", key, value)
+		}
+		if key == "secure" {
+			c.Secure = enabled
+		} else {
+			c.Websocket = enabled
+		}
+	case "timeout":
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q", value)
+		}
+		c.Timeout = timeout
+	default:
+		return fmt.Errorf("unknown config %q", key)
+	}
+	return nil
+}
+
+// loadFile 读取配置文件,每行一个 key = value,# 开头的行为注释
+// required 为 false 时文件不存在不报错
+func (c *config) loadFile(path string, required bool) error {
+	if path == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if !required && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected key = value", path, line)
+		}
+		if err := c.set(strings.TrimSpace(key), value); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// loadEnv 读取环境变量
+func (c *config) loadEnv(getenv func(string) string) error {
+	for key, env := range configKeys {
+		if value := getenv(env); value != "" {
+			if err := c.set(key, value); err != nil {
+				return fmt.Errorf("%s: %w", env, err)
+			}
+		}
+	}
+	return nil
+}
+
+// client 根据配置创建 Aria2Client
+func (c *config) client() *aria2go.Aria2Client {
+	opts := []aria2go.Aria2ClientOption{
+		aria2go.ClientSetAddr(c.Addr),
+		aria2go.ClientSetPort(c.Port),
+		aria2go.ClientSetRPCPath(c.RPCPath),
+		aria2go.ClientSetTimeout(c.Timeout),
+	}
+	if c.Secure {
+		opts = append(opts, aria2go.ClientUseTLS(nil))
+	}
+	if c.Websocket {
+		opts = append(opts, aria2go.ClientUseWebsocket())
+	}
+	return aria2go.NewAria2Client(c.Token, opts...)
+}
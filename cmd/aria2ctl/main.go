@@ -0,0 +1,182 @@
+// aria2ctl 通过 jsonrpc 管理 aria2 的命令行工具
+//
+//	aria2ctl [global flags] <command> [flags] [args]
+//
+// 连接参数可以通过命令行参数 环境变量 ARIA2_ADDR ARIA2_PORT ARIA2_TOKEN 等
+// 或者配置文件 ~/.config/aria2ctl/config 设置
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	aria2go "github.com/gldsly/aria2-go"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr, os.Getenv); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, "aria2ctl:", err)
+		os.Exit(1)
+	}
+}
+
+// app 命令执行时的上下文
+type app struct {
+	ctx    context.Context
+	client *aria2go.Aria2Client
+	out    io.Writer
+	errOut io.Writer
+	format string
+	watch  time.Duration
+}
+
+// command 子命令
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+}
+
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"add":          {"add [-dir DIR] [-out FILE] [-position N] [-pause] [-option KEY=VALUE] URI... | -torrent FILE [WEBSEED...] | -metalink FILE", runAdd},
+		"status":       {"status GID...", runStatus},
+		"ls":           {"ls [active|waiting|stopped|all]", runList},
+		"pause":        {"pause [-force] -all | GID...", runPause},
+		"resume":       {"resume -all | GID...", runResume},
+		"rm":           {"rm [-force] GID...", runRemove},
+		"purge":        {"purge [GID...]", runPurge},
+		"mv":           {"mv [-how set|cur|end] GID POSITION | GID front|back | GID before|after GID", runMove},
+		"options":      {"options get [-global] [GID] | options set [-global] [GID] KEY=VALUE...", runOptions},
+		"stat":         {"stat", runStat},
+		"global":       {"global stat", runGlobal},
+		"version":      {"version", runVersion},
+		"save-session": {"save-session", runSaveSession},
+		"shutdown":     {"shutdown [-force]", runShutdown},
+	}
+}
+
+// run 解析参数并执行子命令
+func run(ctx context.Context, args []string, stdout, stderr io.Writer, getenv func(string) string) error {
+	flags := flag.NewFlagSet("aria2ctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", "", "config file, default $ARIA2CTL_CONFIG or "+defaultConfigPath())
+	flags.String("addr", aria2go.DEFAULT_ARIA2_ADDR, "aria2 address")
+	flags.String("port", aria2go.DEFAULT_ARIA2_PORT, "aria2 rpc port")
+	flags.String("token", "", "aria2 rpc secret")
+	flags.String("rpc-path", aria2go.DEFAULT_RPC_PATH, "aria2 rpc path")
+	flags.Bool("secure", false, "use https/wss")
+	flags.Bool("websocket", false, "use websocket")
+	flags.Duration("timeout", 30*time.Second, "request timeout")
+	format := flags.String("o", "table", "output format: table or json")
+	watch := flags.Duration("watch", 0, "refresh status, ls and stat at this interval until interrupted")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: aria2ctl [global flags] <command> [flags] [args]")
+		fmt.Fprintln(stderr, "\ncommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(stderr, "  "+commands[name].usage)
+		}
+		fmt.Fprintln(stderr, "\nglobal flags:")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("command is required")
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("unknown command %q", flags.Arg(0))
+	}
+
+	cfg := defaultConfig()
+	path, required := *configPath, true
+	if path == "" {
+		path = getenv("ARIA2CTL_CONFIG")
+	}
+	if path == "" {
+		path, required = defaultConfigPath(), false
+	}
+	if err := cfg.loadFile(path, required); err != nil {
+		return err
+	}
+	if err := cfg.loadEnv(getenv); err != nil {
+		return err
+	}
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		if _, ok := configKeys[f.Name]; ok && flagErr == nil {
+			flagErr = cfg.set(f.Name, f.Value.String())
+		}
+	})
+	if flagErr != nil {
+		return flagErr
+	}
+
+	client := cfg.client()
+	defer client.Close()
+	a := &app{ctx: ctx, client: client, out: stdout, errOut: stderr, format: *format, watch: *watch}
+	return cmd.run(a, flags.Args()[1:])
+}
+
+// newFlagSet 创建子命令的参数解析
+func (a *app) newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(a.errOut)
+	flags.Usage = func() {
+		fmt.Fprintln(a.errOut, "usage: aria2ctl "+commands[name].usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// repeat 设置了 -watch 时按间隔重复执行 fn,直到被中断
+// 被中断时正在执行的请求返回的错误不作为失败处理
+func (a *app) repeat(fn func() error) error {
+	if a.watch <= 0 {
+		return fn()
+	}
+	ticker := time.NewTicker(a.watch)
+	defer ticker.Stop()
+	for {
+		if a.format == "table" {
+			fmt.Fprint(a.out, "\033[H\033[2J")
+		}
+		if err := fn(); err != nil {
+			if a.ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case <-a.ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	aria2go "github.com/gldsly/aria2-go"
+	"github.com/gldsly/aria2-go/aria2test"
+)
+
+func TestCommands(t *testing.T) {
+	server := aria2test.NewServer("thanks")
+	defer server.Close()
+	addr := strings.Split(strings.TrimPrefix(server.URL, "http://"), ":")
+	env := map[string]string{"ARIA2_ADDR": addr[0], "ARIA2_PORT": addr[1], "ARIA2_TOKEN": "thanks"}
+	getenv := func(key string) string { return env[key] }
+
+	var stderr bytes.Buffer
+	ctl := func(args ...string) (string, error) {
+		var stdout bytes.Buffer
+		stderr.Reset()
+		err := run(context.Background(), append([]string{"-config", os.DevNull}, args...), &stdout, &stderr, getenv)
+		return stdout.String(), err
+	}
+
+	out, err := ctl("-o", "json", "add", "-pause", "-dir", "/tmp", "http://a/1.iso")
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := map[string][]string{}
+	if err := json.Unmarshal([]byte(out), &added); err != nil || len(added["gids"]) != 1 {
+		t.Fatalf("unexpected add output %q %v", out, err)
+	}
+	gid := added["gids"][0]
+	second, err := ctl("add", "http://a/2.iso")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = ctl("ls", "waiting")
+	if err != nil || !strings.Contains(out, gid) || strings.Contains(out, strings.TrimSpace(second)) || strings.Contains(out, "NAME") {
+		t.Errorf("unexpected ls output %q %v", out, err)
+	}
+	// ls 只查询 listFields,不返回文件列表
+	out, err = ctl("-o", "json", "ls", "waiting")
+	if err != nil || !strings.Contains(out, gid) || !strings.Contains(out, `"files": null`) {
+		t.Errorf("unexpected ls json output %q %v", out, err)
+	}
+	out, err = ctl("status", gid)
+	if err != nil || !strings.Contains(out, "1.iso") {
+		t.Errorf("unexpected status output %q %v", out, err)
+	}
+	out, err = ctl("-o", "json", "status", gid)
+	tasks := make([]*aria2go.TaskStatusData, 0)
+	if err != nil || json.Unmarshal([]byte(out), &tasks) != nil || len(tasks) != 1 || tasks[0].Status != string(aria2go.STATUS_PAUSED) {
+		t.Errorf("unexpected status output %q %v", out, err)
+	}
+
+	if _, err := ctl("options", "set", gid, "max-download-limit=1M", "header=X-A: 1", "header=X-B: 2"); err != nil {
+		t.Error(err)
+	}
+	if _, err := ctl("options", "set", gid, "split=4"); err != nil || !strings.Contains(stderr.String(), "split") {
+		t.Errorf("want restart note got %q %v", stderr.String(), err)
+	}
+	out, err = ctl("options", "get", gid)
+	if err != nil || !strings.Contains(out, "max-download-limit") {
+		t.Errorf("unexpected options output %q %v", out, err)
+	}
+	if _, err := ctl("options", "set", "-global", "max-concurrent-downloads=3"); err != nil {
+		t.Error(err)
+	}
+	if server.GlobalOption("max-concurrent-downloads") != "3" {
+		t.Errorf("global option not changed")
+	}
+	if _, err := ctl("options", "set", gid, "position=0"); err == nil {
+		t.Error("want invalid option error")
+	}
+
+	if _, err := ctl("resume", gid); err != nil {
+		t.Error(err)
+	}
+	if _, err := ctl("pause", "-force", gid); err != nil {
+		t.Error(err)
+	}
+	if _, err := ctl("resume", "-all"); err != nil {
+		t.Error(err)
+	}
+	if _, err := ctl("pause", "-all"); err != nil {
+		t.Error(err)
+	}
+	out, err = ctl("stat")
+	if err != nil || !strings.Contains(out, "ACTIVE") {
+		t.Errorf("unexpected stat output %q %v", out, err)
+	}
+	if _, err := ctl("mv", gid, "front"); err != nil {
+		t.Error(err)
+	}
+	if _, err := ctl("mv", "-how", "bad", gid, "1"); err == nil {
+		t.Error("want invalid -how error")
+	}
+
+	if _, err := ctl("rm", "-force", gid); err != nil {
+		t.Error(err)
+	}
+	if _, err := ctl("purge"); err != nil {
+		t.Error(err)
+	}
+	if _, err := ctl("status", gid); err == nil {
+		t.Error("want gid not found after purge")
+	}
+
+	// -watch 被中断时正常退出
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := run(ctx, []string{"-config", os.DevNull, "-watch", "10ms", "stat"}, io.Discard, io.Discard, getenv); err != nil {
+		t.Errorf("interrupted watch should exit cleanly, got %v", err)
+	}
+	if err := run(ctx, []string{"-config", os.DevNull, "stat"}, io.Discard, io.Discard, getenv); err == nil {
+		t.Error("interrupted command without -watch should fail")
+	}
+
+	if _, err := ctl("nope"); err == nil {
+		t.Error("want unknown command error")
+	}
+	if _, err := ctl("-token", "wrong", "version"); err == nil {
+		t.Error("flag should override env token")
+	}
+	if _, err := ctl("shutdown"); err != nil || !server.IsShutdown() {
+		t.Errorf("shutdown failed %v", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	content := "# aria2ctl\naddr = 10.0.0.1\nport=6801\ntoken = secret\ntimeout = 5s\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := defaultConfig()
+	if err := cfg.loadFile(path, true); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"ARIA2_PORT": "6802", "ARIA2_WEBSOCKET": "true"}
+	if err := cfg.loadEnv(func(key string) string { return env[key] }); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != "10.0.0.1" || cfg.Port != "6802" || cfg.Token != "secret" || cfg.Timeout != 5*time.Second || !cfg.Websocket {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	if err := defaultConfig().loadFile(filepath.Join(t.TempDir(), "missing"), false); err != nil {
+		t.Errorf("missing optional config: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("timeout = soon\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := defaultConfig().loadFile(path, true); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("want line error got %v", err)
+	}
+}
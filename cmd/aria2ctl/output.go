@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	aria2go "github.com/gldsly/aria2-go"
+)
+
+// listFields ls 输出需要的字段,不包含 files bittorrent 等数据量大的字段
+const listFields = aria2go.FIELDS_PROGRESS
+
+// statusFields status 输出需要的字段,额外包含用于显示任务名称和错误信息的字段
+const statusFields = listFields | aria2go.FIELD_FILES | aria2go.FIELD_BITTORRENT |
+	aria2go.FIELD_ERROR_CODE | aria2go.FIELD_ERROR_MESSAGE
+
+// print 按输出格式打印,json 格式直接输出 v,table 格式调用 table
+func (a *app) print(v interface{}, table func(w *tabwriter.Writer) error) error {
+	if a.format == "json" {
+		encoder := json.NewEncoder(a.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	if err := table(w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// printTasks 打印任务列表,withName 为 true 时输出任务名称,需要查询 statusFields
+func (a *app) printTasks(tasks []*aria2go.TaskStatusData, withName bool) error {
+	return a.print(tasks, func(w *tabwriter.Writer) error {
+		header := "GID\tSTATUS\tPROGRESS\tSIZE\tDOWN\tUP\tETA"
+		if withName {
+			header += "\tNAME"
+		}
+		fmt.Fprintln(w, header)
+		for _, task := range tasks {
+			stats, err := task.Stats()
+			if err != nil {
+				return err
+			}
+			eta := "-"
+			if d, ok := stats.ETA(); ok {
+				eta = d.Round(time.Second).String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%.1f%%\t%s\t%s/s\t%s/s\t%s",
+				stats.Gid, stats.Status, stats.Progress()*100, humanBytes(stats.TotalLength),
+				humanBytes(stats.DownloadSpeed), humanBytes(stats.UploadSpeed), eta)
+			if withName {
+				name := taskName(task)
+				if task.ErrorMessage != "" && stats.Status == aria2go.STATUS_ERROR {
+					name += " (" + task.ErrorMessage + ")"
+				}
+				fmt.Fprintf(w, "\t%s", name)
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	})
+}
+
+// printOptions 按参数名排序打印参数
+func (a *app) printOptions(options aria2go.OptionMap) error {
+	return a.print(options, func(w *tabwriter.Writer) error {
+		keys := make([]string, 0, len(options))
+		for key := range options {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fmt.Fprintln(w, "KEY\tVALUE")
+		for _, key := range keys {
+			for _, value := range options.Values(key) {
+				fmt.Fprintf(w, "%s\t%s\n", key, value)
+			}
+		}
+		return nil
+	})
+}
+
+// printGids 打印 gid 列表
+func (a *app) printGids(gids ...string) error {
+	return a.print(map[string][]string{"gids": gids}, func(w *tabwriter.Writer) error {
+		for _, gid := range gids {
+			fmt.Fprintln(w, gid)
+		}
+		return nil
+	})
+}
+
+// printResult 打印 OK 等简单结果
+func (a *app) printResult(result string) error {
+	return a.print(map[string]string{"result": result}, func(w *tabwriter.Writer) error {
+		fmt.Fprintln(w, result)
+		return nil
+	})
+}
+
+// taskName 任务名称,BitTorrent 任务使用种子中的名称,其他任务使用第一个文件名
+func taskName(task *aria2go.TaskStatusData) string {
+	if task.BitTorrent != nil && task.BitTorrent.Info.Name != "" {
+		return task.BitTorrent.Info.Name
+	}
+	if len(task.Files) > 0 {
+		if task.Files[0].Path != "" {
+			return path.Base(task.Files[0].Path)
+		}
+		if len(task.Files[0].Uris) > 0 {
+			return task.Files[0].Uris[0].Uri
+		}
+	}
+	return "-"
+}
+
+// humanBytes 以 1024 为单位格式化字节数
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value, suffix := float64(n), "KMGTPE"
+	i := -1
+	for value >= unit && i < len(suffix)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f%ciB", value, suffix[i])
+}
@@ -3,12 +3,90 @@ package aria2go
 const DEFAULT_JSONRPC_VERSION = "2.0"
 const DEFAULT_ARIA2_PORT = "6800"
 const DEFAULT_ARIA2_ADDR = "127.0.0.1"
+const DEFAULT_RPC_PATH = "/jsonrpc"
 const DEFAULT_CONTENT_TYPE = "application/json"
 
+// DEFAULT_PAGE_SIZE TaskIterator 默认每次请求的任务数量
+const DEFAULT_PAGE_SIZE = 100
+
 type PositionOpt string
 
 const (
 	POS_SET PositionOpt = "POS_SET"
 	POS_CUR PositionOpt = "POS_CUR"
 	POS_END PositionOpt = "POS_END"
-)
\ No newline at end of file
+)
+
+// NotificationType aria2 通知类型
+type NotificationType string
+
+const (
+	ON_DOWNLOAD_START       NotificationType = "aria2.onDownloadStart"
+	ON_DOWNLOAD_PAUSE       NotificationType = "aria2.onDownloadPause"
+	ON_DOWNLOAD_STOP        NotificationType = "aria2.onDownloadStop"
+	ON_DOWNLOAD_COMPLETE    NotificationType = "aria2.onDownloadComplete"
+	ON_DOWNLOAD_ERROR       NotificationType = "aria2.onDownloadError"
+	ON_BT_DOWNLOAD_COMPLETE NotificationType = "aria2.onBtDownloadComplete"
+
+	// ON_CONNECTION_LOST websocket 连接断开,由客户端产生,不是 aria2 的通知
+	ON_CONNECTION_LOST NotificationType = "aria2go.onConnectionLost"
+	// ON_CONNECTION_RESTORED websocket 连接断开后重新连接成功,由客户端产生,断开期间的通知已经丢失
+	ON_CONNECTION_RESTORED NotificationType = "aria2go.onConnectionRestored"
+)
+
+// TaskStatus 任务状态
+type TaskStatus string
+
+const (
+	STATUS_ACTIVE   TaskStatus = "active"
+	STATUS_WAITING  TaskStatus = "waiting"
+	STATUS_PAUSED   TaskStatus = "paused"
+	STATUS_ERROR    TaskStatus = "error"
+	STATUS_COMPLETE TaskStatus = "complete"
+	STATUS_REMOVED  TaskStatus = "removed"
+)
+
+// FileAllocation file-allocation 参数可选值
+type FileAllocation string
+
+const (
+	FILE_ALLOCATION_NONE     FileAllocation = "none"
+	FILE_ALLOCATION_PREALLOC FileAllocation = "prealloc"
+	FILE_ALLOCATION_TRUNC    FileAllocation = "trunc"
+	FILE_ALLOCATION_FALLOC   FileAllocation = "falloc"
+)
+
+// UriSelector uri-selector 参数可选值
+type UriSelector string
+
+const (
+	URI_SELECTOR_INORDER  UriSelector = "inorder"
+	URI_SELECTOR_FEEDBACK UriSelector = "feedback"
+	URI_SELECTOR_ADAPTIVE UriSelector = "adaptive"
+)
+
+// StreamPieceSelector stream-piece-selector 参数可选值
+type StreamPieceSelector string
+
+const (
+	STREAM_PIECE_SELECTOR_DEFAULT StreamPieceSelector = "default"
+	STREAM_PIECE_SELECTOR_INORDER StreamPieceSelector = "inorder"
+	STREAM_PIECE_SELECTOR_RANDOM  StreamPieceSelector = "random"
+	STREAM_PIECE_SELECTOR_GEOM    StreamPieceSelector = "geom"
+)
+
+// FTPType ftp-type 参数可选值
+type FTPType string
+
+const (
+	FTP_TYPE_BINARY FTPType = "binary"
+	FTP_TYPE_ASCII  FTPType = "ascii"
+)
+
+// ProxyMethod proxy-method 参数可选值
+type ProxyMethod string
+
+const (
+	PROXY_METHOD_GET    ProxyMethod = "get"
+	PROXY_METHOD_TUNNEL ProxyMethod = "tunnel"
+)
@@ -0,0 +1,136 @@
+package aria2go
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized 访问令牌错误
+	ErrUnauthorized = errors.New("aria2: unauthorized")
+	// ErrGIDNotFound 指定的 gid 不存在
+	ErrGIDNotFound = errors.New("aria2: gid not found")
+	// ErrTransport 与 aria2 通信失败,例如连接失败或 http 状态码错误
+	ErrTransport = errors.New("aria2: transport error")
+	// ErrDecode 解析 aria2 响应数据失败
+	ErrDecode = errors.New("aria2: decode response error")
+	// ErrInvalidOption 参数值不符合 aria2 的要求
+	ErrInvalidOption = errors.New("aria2: invalid option")
+	// ErrDownloadFailed 任务下载失败或者被删除
+	ErrDownloadFailed = errors.New("aria2: download failed")
+	// ErrPieceLengthRequired 解析 bitfield 时没有传入分片大小,无法计算字节范围
+	ErrPieceLengthRequired = errors.New("aria2: bitfield piece length is required")
+)
+
+// RPCError aria2 返回的 jsonrpc 错误
+type RPCError struct {
+	Code    int
+	Message string
+	// Method 请求的方法名
+	Method string
+	// ID 请求的 ReplayID
+	ID string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("aria2 rpc error: method: %s code: %d message: %s", e.Method, e.Code, e.Message)
+}
+
+// Is 支持 errors.Is 判断 ErrUnauthorized ErrGIDNotFound
+// aria2 的错误码都为 1,只能通过错误信息区分
+func (e *RPCError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Message == "Unauthorized"
+	case ErrGIDNotFound:
+		return strings.Contains(e.Message, "GID") &&
+			(strings.Contains(e.Message, "not found") || strings.Contains(e.Message, "No such download"))
+	}
+	return false
+}
+
+// DownloadError 任务下载失败或者被删除,Wait 等待的任务没有完成时返回
+type DownloadError struct {
+	Gid    string
+	Status TaskStatus
+	// ErrorCode aria2 的错误码,参考 aria2 文档 EXIT STATUS,任务被删除时为 0
+	ErrorCode    int
+	ErrorMessage string
+}
+
+func (e *DownloadError) Error() string {
+	if e.Status == STATUS_REMOVED {
+		return fmt.Sprintf("aria2 download %s was removed", e.Gid)
+	}
+	return fmt.Sprintf("aria2 download %s failed: code: %d message: %s", e.Gid, e.ErrorCode, e.ErrorMessage)
+}
+
+func (e *DownloadError) Is(target error) bool {
+	return target == ErrDownloadFailed
+}
+
+// TransportError 与 aria2 通信失败
+type TransportError struct {
+	// StatusCode http 状态码,非 http 错误时为 0
+	StatusCode int
+	Err        error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("aria2 transport error: %v", e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TransportError) Is(target error) bool {
+	return target == ErrTransport
+}
+
+// DecodeError 解析 aria2 响应数据失败
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("aria2 decode response error: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+// responseModel 所有嵌入 BasicModel 的响应结构体
+type responseModel interface {
+	basic() *BasicModel
+}
+
+func (b *BasicModel) basic() *BasicModel {
+	return b
+}
+
+// decodeResponse 解析 aria2 响应数据
+// 解析失败时返回 *DecodeError,响应中包含错误时返回 *RPCError
+func decodeResponse(method string, data []byte, resp responseModel) error {
+	if err := json.Unmarshal(data, resp); err != nil {
+		return &DecodeError{Err: err}
+	}
+
+	basic := resp.basic()
+	if basic.Error != nil {
+		return &RPCError{
+			Code:    basic.Error.Code,
+			Message: basic.Error.Message,
+			Method:  method,
+			ID:      basic.ID,
+		}
+	}
+	return nil
+}
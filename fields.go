@@ -0,0 +1,140 @@
+package aria2go
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TaskField tellStatus tellActive tellWaiting tellStopped 中 keys 参数的类型化字段选择,多个字段通过 | 组合
+// 只查询需要的字段可以避免每次都返回 files bittorrent 等数据量较大的字段
+//
+//	status, err := client.QueryTaskStatusFields(gid, aria2go.FIELD_GID|aria2go.FIELD_STATUS|aria2go.FIELD_COMPLETED_LENGTH)
+type TaskField uint64
+
+// 字段顺序与 TaskStatusData 的字段顺序一致,字段名取自 json tag
+const (
+	FIELD_GID TaskField = 1 << iota
+	FIELD_STATUS
+	FIELD_TOTAL_LENGTH
+	FIELD_COMPLETED_LENGTH
+	FIELD_UPLOAD_LENGTH
+	FIELD_BITFIELD
+	FIELD_DOWNLOAD_SPEED
+	FIELD_UPLOAD_SPEED
+	FIELD_INFO_HASH
+	FIELD_NUM_SEEDERS
+	FIELD_SEEDER
+	FIELD_PIECE_LENGTH
+	FIELD_NUM_PIECES
+	FIELD_CONNECTIONS
+	FIELD_ERROR_CODE
+	FIELD_ERROR_MESSAGE
+	FIELD_FOLLOWED_BY
+	FIELD_FOLLOWING
+	FIELD_BELONGS_TO
+	FIELD_DIR
+	FIELD_FILES
+	FIELD_BITTORRENT
+	FIELD_VERIFIED_LENGTH
+	FIELD_VERIFY_INTEGRITY_PENDING
+)
+
+// FIELDS_PROGRESS 计算下载进度需要的字段
+const FIELDS_PROGRESS = FIELD_GID | FIELD_STATUS | FIELD_TOTAL_LENGTH | FIELD_COMPLETED_LENGTH |
+	FIELD_DOWNLOAD_SPEED | FIELD_UPLOAD_SPEED
+
+var (
+	taskFieldOnce sync.Once
+	taskFieldKeys []string
+)
+
+// getTaskFieldKeys TaskStatusData 的 json tag,第 i 个元素对应 1 << i
+func getTaskFieldKeys() []string {
+	taskFieldOnce.Do(func() {
+		t := reflect.TypeOf(TaskStatusData{})
+		taskFieldKeys = make([]string, 0, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			taskFieldKeys = append(taskFieldKeys, t.Field(i).Tag.Get("json"))
+		}
+	})
+	return taskFieldKeys
+}
+
+// Keys 转换为 keys 参数
+func (f TaskField) Keys() []string {
+	keys := make([]string, 0)
+	for i, key := range getTaskFieldKeys() {
+		if f&(1<<i) != 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (f TaskField) String() string {
+	return strings.Join(f.Keys(), "|")
+}
+
+// ValidateTaskKeys 校验 keys 是否都是 TaskStatusData 中的字段
+func ValidateTaskKeys(keys ...string) error {
+	for _, key := range keys {
+		found := false
+		for _, item := range getTaskFieldKeys() {
+			if item == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown task status key %q", key)
+		}
+	}
+	return nil
+}
+
+// QueryTaskStatusFields 查询任务状态,只返回 fields 中的字段
+func (a Aria2Client) QueryTaskStatusFields(gid string, fields TaskField) (status *TaskStatusData, err error) {
+	return a.QueryTaskStatusFieldsContext(context.Background(), gid, fields)
+}
+
+// QueryTaskStatusFieldsContext 同 QueryTaskStatusFields,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) QueryTaskStatusFieldsContext(ctx context.Context, gid string, fields TaskField) (status *TaskStatusData, err error) {
+	status, _, err = CallContext[*TaskStatusData](ctx, &a, NewRequestWithToken(a.Token).TellStatus(gid, fields.Keys()...))
+	return
+}
+
+// QueryDownloadingTaskFields 查询正在下载的任务,只返回 fields 中的字段
+func (a Aria2Client) QueryDownloadingTaskFields(fields TaskField) (tasks []*TaskStatusData, err error) {
+	return a.QueryDownloadingTaskFieldsContext(context.Background(), fields)
+}
+
+// QueryDownloadingTaskFieldsContext 同 QueryDownloadingTaskFields,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) QueryDownloadingTaskFieldsContext(ctx context.Context, fields TaskField) (tasks []*TaskStatusData, err error) {
+	tasks, _, err = CallContext[[]*TaskStatusData](ctx, &a, NewRequestWithToken(a.Token).TellActive(fields.Keys()...))
+	return
+}
+
+// QueryWaitingTaskFields 查询等待中的任务,只返回 fields 中的字段
+func (a Aria2Client) QueryWaitingTaskFields(offset, limit int, fields TaskField) (tasks []*TaskStatusData, err error) {
+	return a.QueryWaitingTaskFieldsContext(context.Background(), offset, limit, fields)
+}
+
+// QueryWaitingTaskFieldsContext 同 QueryWaitingTaskFields,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) QueryWaitingTaskFieldsContext(ctx context.Context, offset, limit int, fields TaskField) (tasks []*TaskStatusData, err error) {
+	tasks, _, err = CallContext[[]*TaskStatusData](ctx, &a, NewRequestWithToken(a.Token).TellWaiting(offset, limit, fields.Keys()...))
+	return
+}
+
+// QueryStoppedTaskFields 查询已停止的任务,只返回 fields 中的字段
+func (a Aria2Client) QueryStoppedTaskFields(offset, limit int, fields TaskField) (tasks []*TaskStatusData, err error) {
+	return a.QueryStoppedTaskFieldsContext(context.Background(), offset, limit, fields)
+}
+
+// QueryStoppedTaskFieldsContext 同 QueryStoppedTaskFields,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) QueryStoppedTaskFieldsContext(ctx context.Context, offset, limit int, fields TaskField) (tasks []*TaskStatusData, err error) {
+	tasks, _, err = CallContext[[]*TaskStatusData](ctx, &a, NewRequestWithToken(a.Token).TellStopped(offset, limit, fields.Keys()...))
+	return
+}
@@ -0,0 +1,39 @@
+package aria2go
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestTaskField(t *testing.T) {
+	if n := reflect.TypeOf(TaskStatusData{}).NumField(); FIELD_VERIFY_INTEGRITY_PENDING != 1<<(n-1) {
+		t.Fatalf("TaskField constants do not match %d TaskStatusData fields", n)
+	}
+	if keys := FIELD_FOLLOWED_BY.Keys(); len(keys) != 1 || keys[0] != "followedBy" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+	if FIELDS_PROGRESS.String() != "gid|status|totalLength|completedLength|downloadSpeed|uploadSpeed" {
+		t.Errorf("unexpected fields %s", FIELDS_PROGRESS)
+	}
+	if _, _, err := NewRequestWithToken("thanks").TellActive("gid", "complete").Create(); err == nil {
+		t.Error("want unknown key error")
+	}
+
+	var keys interface{}
+	fieldClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &RequestBody{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		keys = request.Params[2]
+		_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","result":{"gid":"2089b05ecca3d829","status":"active","completedLength":"10"}}`, request.ReplayID)
+	}))
+	status, err := fieldClient.QueryTaskStatusFields("2089b05ecca3d829", FIELD_GID|FIELD_STATUS|FIELD_COMPLETED_LENGTH)
+	if err != nil || status.CompletedLength != "10" {
+		t.Fatalf("QueryTaskStatusFields %v %v", status, err)
+	}
+	if !reflect.DeepEqual(keys, []interface{}{"gid", "status", "completedLength"}) {
+		t.Errorf("unexpected keys param %v", keys)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/gldsly/aria2-go
 
 go 1.18
 
-require github.com/google/uuid v1.3.0
+require (
+	github.com/google/uuid v1.3.0
+	github.com/gorilla/websocket v1.5.3
+)
@@ -0,0 +1,123 @@
+package aria2go
+
+import "context"
+
+// TaskIterator 按页懒加载 tellWaiting tellStopped 的结果
+// 只有当前页的数据保存在内存中,当前页遍历完后才请求下一页,不再调用 Next 即可提前结束
+// 遍历过程中队列发生变化时,可能会重复或遗漏任务
+//
+//	iter := client.IterStopped(0, 500, "gid", "status")
+//	for iter.Next() {
+//		fmt.Println(iter.Task().Gid)
+//	}
+//	if err := iter.Err(); err != nil {
+//		return err
+//	}
+type TaskIterator struct {
+	client   *Aria2Client
+	ctx      context.Context
+	waiting  bool
+	offset   int
+	pageSize int
+	keys     []string
+
+	page  []*TaskStatusData
+	index int
+	task  *TaskStatusData
+	done  bool
+	err   error
+}
+
+// IterWaiting 遍历等待队列中的任务
+// offset 为负数时从队列末尾开始倒序遍历,-1 为最后一个任务
+// pageSize 小于等于 0 时使用 DEFAULT_PAGE_SIZE,keys 为需要返回的字段,可以使用 TaskField.Keys 生成,不传递时返回所有字段
+func (a Aria2Client) IterWaiting(offset, pageSize int, keys ...string) *TaskIterator {
+	return a.IterWaitingContext(context.Background(), offset, pageSize, keys...)
+}
+
+// IterWaitingContext 同 IterWaiting,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) IterWaitingContext(ctx context.Context, offset, pageSize int, keys ...string) *TaskIterator {
+	return newTaskIterator(ctx, &a, true, offset, pageSize, keys)
+}
+
+// IterStopped 遍历已停止的任务,参数同 IterWaiting
+func (a Aria2Client) IterStopped(offset, pageSize int, keys ...string) *TaskIterator {
+	return a.IterStoppedContext(context.Background(), offset, pageSize, keys...)
+}
+
+// IterStoppedContext 同 IterStopped,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) IterStoppedContext(ctx context.Context, offset, pageSize int, keys ...string) *TaskIterator {
+	return newTaskIterator(ctx, &a, false, offset, pageSize, keys)
+}
+
+func newTaskIterator(ctx context.Context, client *Aria2Client, waiting bool, offset, pageSize int, keys []string) *TaskIterator {
+	if pageSize <= 0 {
+		pageSize = DEFAULT_PAGE_SIZE
+	}
+	return &TaskIterator{
+		client:   client,
+		ctx:      ctx,
+		waiting:  waiting,
+		offset:   offset,
+		pageSize: pageSize,
+		keys:     keys,
+	}
+}
+
+// Next 移动到下一个任务,没有更多任务或者请求失败时返回 false
+func (t *TaskIterator) Next() bool {
+	if t.err != nil {
+		return false
+	}
+	for t.index >= len(t.page) {
+		if t.done {
+			t.task = nil
+			return false
+		}
+		if err := t.fetch(); err != nil {
+			t.err = err
+			t.task = nil
+			return false
+		}
+	}
+	t.task = t.page[t.index]
+	t.index++
+	return true
+}
+
+// fetch 请求下一页数据
+// aria2 对负数 offset 倒序返回结果,下一页的 offset 需要继续向前移动
+func (t *TaskIterator) fetch() error {
+	request := NewRequestWithToken(t.client.Token)
+	if t.waiting {
+		request.TellWaiting(t.offset, t.pageSize, t.keys...)
+	} else {
+		request.TellStopped(t.offset, t.pageSize, t.keys...)
+	}
+	page, _, err := CallContext[[]*TaskStatusData](t.ctx, t.client, request)
+	if err != nil {
+		return err
+	}
+
+	t.page = page
+	t.index = 0
+	if len(page) < t.pageSize {
+		t.done = true
+	}
+	if t.offset < 0 {
+		t.offset -= len(page)
+	} else {
+		t.offset += len(page)
+	}
+	return nil
+}
+
+// Task 当前任务,需要在 Next 返回 true 之后调用
+func (t *TaskIterator) Task() *TaskStatusData {
+	return t.task
+}
+
+// Err 遍历过程中的错误
+func (t *TaskIterator) Err() error {
+	return t.err
+}
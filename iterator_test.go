@@ -0,0 +1,77 @@
+package aria2go
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestTaskIterator(t *testing.T) {
+	stopped := make([]string, 0)
+	for i := 0; i < 7; i++ {
+		stopped = append(stopped, fmt.Sprintf("gid%d", i))
+	}
+	requests := 0
+	iterClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		request := &RequestBody{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		offset, num := int(request.Params[1].(float64)), int(request.Params[2].(float64))
+
+		// 负数 offset 从末尾开始倒序返回
+		items := make([]map[string]string, 0)
+		if offset < 0 {
+			for i := len(stopped) + offset; i >= 0 && len(items) < num; i-- {
+				items = append(items, map[string]string{"gid": stopped[i]})
+			}
+		} else {
+			for i := offset; i < len(stopped) && len(items) < num; i++ {
+				items = append(items, map[string]string{"gid": stopped[i]})
+			}
+		}
+		data, _ := json.Marshal(items)
+		_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","result":%s}`, request.ReplayID, data)
+	}))
+
+	collect := func(iter *TaskIterator) []string {
+		t.Helper()
+		gids := make([]string, 0)
+		for iter.Next() {
+			gids = append(gids, iter.Task().Gid)
+		}
+		if err := iter.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return gids
+	}
+
+	if gids := collect(iterClient.IterStopped(0, 3, "gid")); !reflect.DeepEqual(gids, stopped) {
+		t.Errorf("unexpected gids %v", gids)
+	}
+	if requests != 3 {
+		t.Errorf("want 3 requests got %d", requests)
+	}
+
+	want := []string{"gid6", "gid5", "gid4", "gid3", "gid2", "gid1", "gid0"}
+	if gids := collect(iterClient.IterStopped(-1, 3)); !reflect.DeepEqual(gids, want) {
+		t.Errorf("unexpected reversed gids %v", gids)
+	}
+
+	requests = 0
+	iter := iterClient.IterStopped(0, 2)
+	for i := 0; i < 3 && iter.Next(); i++ {
+	}
+	if requests != 2 {
+		t.Errorf("early stop want 2 requests got %d", requests)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	iter = iterClient.IterStoppedContext(ctx, 0, 2)
+	if iter.Next() || iter.Err() == nil {
+		t.Error("want canceled error")
+	}
+}
@@ -16,7 +16,7 @@ type Option struct {
 	AsyncDns                      string `json:"async-dns"`
 	AutoFileRenaming              string `json:"auto-file-renaming"`
 	BTEnableHookAfterHashCheck    string `json:"bt-enable-hook-after-hash-check"`
-	BTEnableLpd                   string `json:"bt-enable-Lpd"`
+	BTEnableLpd                   string `json:"bt-enable-lpd"`
 	BTExcludeTracker              string `json:"bt-exclude-tracker"`
 	BTExternalIp                  string `json:"bt-external-ip"`
 	BTForceEncryption             string `json:"bt-force-encryption"`
@@ -114,7 +114,9 @@ type Option struct {
 	UriSelector                   string `json:"uri-selector"`
 	UseHead                       string `json:"use-head"`
 	UserAgent                     string `json:"user-agent"`
-	Position                      string `json:"position"`
+	// Deprecated: position 不是 aria2 的参数,设置后请求会返回错误
+	// 使用 AddUri AddTorrentData AddMetalinkData 的 position 参数设置新任务在等待队列中的位置
+	Position string `json:"position"`
 }
 
 type MultiCallParamsItem struct {
@@ -132,30 +134,75 @@ type GlobalStatData struct {
 	NumStoppedTotal string `json:"numStoppedTotal"`
 }
 
+// PeerInfo GetPeers 返回值结构
+type PeerInfo struct {
+	PeerId        string `json:"peerId"`
+	Ip            string `json:"ip"`
+	Port          string `json:"port"`
+	BitField      string `json:"bitfield"`
+	AmChoking     string `json:"amChoking"`
+	PeerChoking   string `json:"peerChoking"`
+	DownloadSpeed string `json:"downloadSpeed"`
+	UploadSpeed   string `json:"uploadSpeed"`
+	Seeder        string `json:"seeder"`
+}
+
+// ServerInfo GetServers 返回值结构
+type ServerInfo struct {
+	Index   string            `json:"index"`
+	Servers []*ServerInfoItem `json:"servers"`
+}
+
+type ServerInfoItem struct {
+	Uri           string `json:"uri"`
+	CurrentUri    string `json:"currentUri"`
+	DownloadSpeed string `json:"downloadSpeed"`
+}
+
+// SessionInfo GetSessionInfo 返回值结构
+type SessionInfo struct {
+	SessionId string `json:"sessionId"`
+}
+
+// GetVersionResponse GetVersion 返回值结构
 type GetVersionResponse struct {
 	Version         string   `json:"version"`
 	EnabledFeatures []string `json:"enabledFeatures"`
 }
 
 // TaskStatusData TellStatus 返回值结构
+// 参考 http://aria2.github.io/manual/en/html/aria2c.html#aria2.tellStatus
 type TaskStatusData struct {
-	Gid             string                    `json:"gid"`
-	InfoHash        string                    `json:"infoHash"`
-	NumPieces       string                    `json:"numPieces"`
-	NumSeeders      string                    `json:"numSeeders"`
-	PieceLength     string                    `json:"pieceLength"`
-	Seeder          string                    `json:"seeder"`
-	Status          string                    `json:"status"`
-	TotalLength     string                    `json:"totalLength"`
-	CompletedLength string                    `json:"completedLength"`
-	DownloadSpeed   string                    `json:"downloadSpeed"`
-	UploadLength    string                    `json:"uploadLength"`
-	UploadSpeed     string                    `json:"uploadSpeed"`
-	Connections     string                    `json:"connections"`
-	Dir             string                    `json:"dir"`
-	Files           []*TaskStatusDataFile     `json:"files"`
-	BitField        string                    `json:"bitfield"`
-	BitTorrent      *TaskStatusDataBitTorrent `json:"bittorrent"`
+	Gid             string `json:"gid"`
+	Status          string `json:"status"`
+	TotalLength     string `json:"totalLength"`
+	CompletedLength string `json:"completedLength"`
+	UploadLength    string `json:"uploadLength"`
+	BitField        string `json:"bitfield"`
+	DownloadSpeed   string `json:"downloadSpeed"`
+	UploadSpeed     string `json:"uploadSpeed"`
+	InfoHash        string `json:"infoHash"`
+	NumSeeders      string `json:"numSeeders"`
+	Seeder          string `json:"seeder"`
+	PieceLength     string `json:"pieceLength"`
+	NumPieces       string `json:"numPieces"`
+	Connections     string `json:"connections"`
+	// ErrorCode 任务失败时的错误码,参考 aria2 文档 EXIT STATUS
+	ErrorCode    string `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+	// FollowedBy 由当前任务生成的任务,例如 magnet 或 .torrent 下载完成后生成的 BitTorrent 任务
+	FollowedBy []string `json:"followedBy"`
+	// Following 生成当前任务的任务,与 FollowedBy 相对
+	Following string `json:"following"`
+	// BelongsTo 当前任务所属的父任务
+	BelongsTo  string                    `json:"belongsTo"`
+	Dir        string                    `json:"dir"`
+	Files      []*TaskStatusDataFile     `json:"files"`
+	BitTorrent *TaskStatusDataBitTorrent `json:"bittorrent"`
+	// VerifiedLength 正在校验的数据长度,只在校验时返回
+	VerifiedLength string `json:"verifiedLength"`
+	// VerifyIntegrityPending 为 true 时任务正在等待校验
+	VerifyIntegrityPending string `json:"verifyIntegrityPending"`
 }
 
 type TaskStatusDataFile struct {
@@ -173,15 +220,20 @@ type TaskStatusDataFileUris struct {
 }
 
 type TaskStatusDataBitTorrent struct {
-	AnnounceList [][]string `json:"announceList"`
-	Comment      string     `json:"comment"`
-	CreationDate int64      `json:"creationDate"`
-	Info         struct {
-		Name string `json:"name"`
-	} `json:"info"`
+	AnnounceList [][]string                   `json:"announceList"`
+	Comment      string                       `json:"comment"`
+	CreationDate int64                        `json:"creationDate"`
+	Info         TaskStatusDataBitTorrentInfo `json:"info"`
+	// Mode 文件模式 single 或 multi
 	Mode string `json:"mode"`
 }
 
+// TaskStatusDataBitTorrentInfo 种子 info 字典中的数据
+// Name 优先使用 name.utf-8,magnet 任务在元数据下载完成前没有 info
+type TaskStatusDataBitTorrentInfo struct {
+	Name string `json:"name"`
+}
+
 type GetFilesResponse struct {
 	BasicModel
 	Result []*TaskStatusDataFile `json:"result"`
@@ -0,0 +1,134 @@
+package aria2go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// MultiCallBatch 通过 system.multicall 一次发送多个请求
+// 每个请求单独返回结果和错误,某个请求失败不影响其他请求的结果
+//
+//	batch := client.NewMultiCallBatch()
+//	status := aria2go.AddMultiCall[*aria2go.TaskStatusData](batch, aria2go.NewRequestWithToken(client.Token).TellStatus(gid))
+//	pause := aria2go.AddMultiCall[string](batch, aria2go.NewRequestWithToken(client.Token).Pause(gid, false))
+//	if err := batch.Send(); err != nil {
+//		return err
+//	}
+//	fmt.Println(status.Result, status.Err, pause.Err)
+type MultiCallBatch struct {
+	client *Aria2Client
+	items  []multiCallItem
+}
+
+// MultiCallResult MultiCallBatch 中单个请求的结果
+// 调用 MultiCallBatch.Send 之后才有数据
+type MultiCallResult[T any] struct {
+	Result T
+	// Err 为该请求的错误,aria2 返回的错误为 *RPCError
+	Err error
+}
+
+// multiCallItem MultiCallBatch 中的单个请求
+type multiCallItem interface {
+	request() *RequestBody
+	decode(data json.RawMessage, replayID string)
+	fail(err error)
+}
+
+type multiCallResultItem[T any] struct {
+	req    *RequestBody
+	result *MultiCallResult[T]
+}
+
+func (m *multiCallResultItem[T]) request() *RequestBody {
+	return m.req
+}
+
+// decode 解析单个请求的结果
+// 成功时 aria2 返回只有一个元素的数组,失败时返回错误结构
+func (m *multiCallResultItem[T]) decode(data json.RawMessage, replayID string) {
+	*m.result = MultiCallResult[T]{}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		respErr := &ResponseError{}
+		if err := json.Unmarshal(data, respErr); err != nil {
+			m.fail(&DecodeError{Err: err})
+			return
+		}
+		m.fail(&RPCError{Code: respErr.Code, Message: respErr.Message, Method: m.req.Method, ID: replayID})
+		return
+	}
+
+	values := make([]json.RawMessage, 0, 1)
+	if err := json.Unmarshal(data, &values); err != nil {
+		m.fail(&DecodeError{Err: err})
+		return
+	}
+	if len(values) != 1 {
+		m.fail(&DecodeError{Err: fmt.Errorf("%s multicall result length %d", m.req.Method, len(values))})
+		return
+	}
+	if err := json.Unmarshal(values[0], &m.result.Result); err != nil {
+		m.fail(&DecodeError{Err: err})
+	}
+}
+
+func (m *multiCallResultItem[T]) fail(err error) {
+	m.result.Err = err
+}
+
+// NewMultiCallBatch 创建 system.multicall 批量请求
+func (a Aria2Client) NewMultiCallBatch() *MultiCallBatch {
+	return &MultiCallBatch{client: &a}
+}
+
+// AddMultiCall 添加请求到 batch 中,返回的结果在 batch 发送后可用
+// request 需要设置 token
+func AddMultiCall[T any](batch *MultiCallBatch, request *RequestBody) *MultiCallResult[T] {
+	result := &MultiCallResult[T]{}
+	batch.items = append(batch.items, &multiCallResultItem[T]{req: request, result: result})
+	return result
+}
+
+// Len batch 中的请求数量
+func (b *MultiCallBatch) Len() int {
+	return len(b.items)
+}
+
+// Send 发送 batch 中的所有请求
+func (b *MultiCallBatch) Send() error {
+	return b.SendContext(context.Background())
+}
+
+// SendContext 同 Send,通过 ctx 控制请求的取消和超时
+// 只有整个请求失败时才返回错误,同时所有 MultiCallResult 的 Err 都会设置为该错误
+// 单个请求的错误只记录在对应的 MultiCallResult 中
+func (b *MultiCallBatch) SendContext(ctx context.Context) (err error) {
+	defer func() {
+		if err != nil {
+			for _, item := range b.items {
+				item.fail(err)
+			}
+		}
+	}()
+
+	requests := make([]*RequestBody, 0, len(b.items))
+	for _, item := range b.items {
+		requests = append(requests, item.request())
+	}
+
+	results, replayID, err := CallContext[[]json.RawMessage](ctx, b.client, NewRequest().MultiCall(requests...))
+	if err != nil {
+		return err
+	}
+	if len(results) != len(b.items) {
+		return &DecodeError{Err: fmt.Errorf("multicall result length %d, want %d", len(results), len(b.items))}
+	}
+
+	for i, item := range b.items {
+		item.decode(results[i], replayID)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package aria2go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// NotificationEvent aria2 通知事件
+type NotificationEvent struct {
+	Type NotificationType
+	Gid  string
+	// Err ON_CONNECTION_LOST 事件中连接断开的原因
+	Err error
+}
+
+// NotificationHandler 通知回调函数
+type NotificationHandler func(event *NotificationEvent)
+
+// notificationMessage aria2 通知原始数据
+type notificationMessage struct {
+	Method string `json:"method"`
+	Params []struct {
+		Gid string `json:"gid"`
+	} `json:"params"`
+}
+
+// notificationSubscriber 通知订阅者
+type notificationSubscriber struct {
+	handler NotificationHandler
+	types   map[NotificationType]bool
+}
+
+// accept 是否接收该类型的通知,连接断开和恢复的事件总是接收
+func (s *notificationSubscriber) accept(t NotificationType) bool {
+	return len(s.types) == 0 || s.types[t] || t == ON_CONNECTION_LOST || t == ON_CONNECTION_RESTORED
+}
+
+// decodeNotification 解析 aria2 通知
+func decodeNotification(data []byte) ([]*NotificationEvent, error) {
+	msg := &notificationMessage{}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+
+	events := make([]*NotificationEvent, 0, len(msg.Params))
+	for _, item := range msg.Params {
+		events = append(events, &NotificationEvent{
+			Type: NotificationType(msg.Method),
+			Gid:  item.Gid,
+		})
+	}
+	return events, nil
+}
+
+// OnNotification 注册通知回调
+// 只能在使用 websocket 通信时使用,types 为空时接收所有类型的通知
+// handler 在读取连接的协程中按顺序调用,不能阻塞,否则会影响请求响应的接收
+// 连接断开时 handler 收到 ON_CONNECTION_LOST 事件,之后在后台按逐渐增加的间隔重新连接,
+// 连接恢复时收到 ON_CONNECTION_RESTORED 事件,断开期间的通知会丢失,调用 Close 之后不再重新连接
+// 调用返回的 cancel 函数取消订阅
+func (a Aria2Client) OnNotification(handler NotificationHandler, types ...NotificationType) (cancel func(), err error) {
+	if a.ws == nil {
+		return nil, errors.New("notification requires websocket, use ClientUseWebsocket")
+	}
+	if handler == nil {
+		return nil, errors.New("notification handler is required")
+	}
+
+	subscriber := &notificationSubscriber{handler: handler}
+	if len(types) > 0 {
+		subscriber.types = make(map[NotificationType]bool)
+		for _, t := range types {
+			subscriber.types[t] = true
+		}
+	}
+
+	id := a.ws.subscribe(subscriber)
+	if _, err := a.ws.connect(context.Background()); err != nil {
+		a.ws.unsubscribe(id)
+		return nil, err
+	}
+
+	once := sync.Once{}
+	cancel = func() {
+		once.Do(func() {
+			a.ws.unsubscribe(id)
+		})
+	}
+	return cancel, nil
+}
+
+// Notifications 以 channel 的形式接收通知
+// 通知会在内部排队,接收方处理慢不会阻塞连接,也不会丢失通知
+// 连接断开时发送完已收到的通知后关闭 channel 并取消订阅,需要时重新调用 Notifications
+// 调用返回的 cancel 函数取消订阅并关闭 channel
+func (a Aria2Client) Notifications(types ...NotificationType) (events <-chan *NotificationEvent, cancel func(), err error) {
+	queue := newNotificationQueue()
+	unsubscribe, err := a.OnNotification(func(event *NotificationEvent) {
+		switch event.Type {
+		case ON_CONNECTION_LOST:
+			// nil 标记队列结束
+			queue.push(nil)
+		case ON_CONNECTION_RESTORED:
+		default:
+			queue.push(event)
+		}
+	}, types...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make(chan *NotificationEvent)
+	go func() {
+		queue.forward(out)
+		unsubscribe()
+	}()
+
+	once := sync.Once{}
+	cancel = func() {
+		once.Do(func() {
+			unsubscribe()
+			queue.close()
+		})
+	}
+	return out, cancel, nil
+}
+
+// notificationQueue 无界通知队列
+type notificationQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	events []*NotificationEvent
+	closed bool
+	done   chan struct{}
+}
+
+func newNotificationQueue() *notificationQueue {
+	q := &notificationQueue{done: make(chan struct{})}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *notificationQueue) push(event *NotificationEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.events = append(q.events, event)
+	q.cond.Signal()
+}
+
+func (q *notificationQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.done)
+	q.cond.Signal()
+}
+
+// forward 将队列中的通知依次发送到 out,队列关闭或者遇到 nil 后关闭 out
+func (q *notificationQueue) forward(out chan<- *NotificationEvent) {
+	defer close(out)
+	for {
+		q.mu.Lock()
+		for len(q.events) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return
+		}
+		event := q.events[0]
+		q.events = q.events[1:]
+		q.mu.Unlock()
+		if event == nil {
+			return
+		}
+
+		select {
+		case out <- event:
+		case <-q.done:
+			return
+		}
+	}
+}
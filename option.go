@@ -0,0 +1,721 @@
+package aria2go
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ByteSize 字节大小,用于 max-download-limit min-split-size 等参数
+type ByteSize int64
+
+const (
+	KiB ByteSize = 1024
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+)
+
+// String 转换为 aria2 的格式,可以整除时使用 K M 后缀
+func (b ByteSize) String() string {
+	switch {
+	case b != 0 && b%MiB == 0:
+		return strconv.FormatInt(int64(b/MiB), 10) + "M"
+	case b != 0 && b%KiB == 0:
+		return strconv.FormatInt(int64(b/KiB), 10) + "K"
+	}
+	return strconv.FormatInt(int64(b), 10)
+}
+
+// ParseByteSize 解析 aria2 格式的字节大小,例如 1M 512K 1024
+func ParseByteSize(value string) (ByteSize, error) {
+	value = strings.TrimSpace(value)
+	unit := ByteSize(1)
+	if value != "" {
+		switch value[len(value)-1] {
+		case 'K', 'k':
+			unit = KiB
+			value = value[:len(value)-1]
+		case 'M', 'm':
+			unit = MiB
+			value = value[:len(value)-1]
+		}
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid byte size %q", value)
+	}
+	return ByteSize(number) * unit, nil
+}
+
+type optionKind int
+
+const (
+	optionString optionKind = iota
+	optionBool
+	optionInt
+	optionSize
+	optionSeconds
+	optionFloat
+	optionEnum
+	optionGid
+	optionIndexOut
+)
+
+// optionSpec 参数的类型和取值范围
+// max 为 -1 时不限制最大值
+// list 为 true 时参数可以设置多个值,在 Option 中以换行分隔,发送时转换为数组
+type optionSpec struct {
+	kind   optionKind
+	min    int64
+	max    int64
+	values []string
+	list   bool
+}
+
+func boolOption() *optionSpec {
+	return &optionSpec{kind: optionBool}
+}
+
+func intOption(min, max int64) *optionSpec {
+	return &optionSpec{kind: optionInt, min: min, max: max}
+}
+
+func sizeOption(min, max ByteSize) *optionSpec {
+	return &optionSpec{kind: optionSize, min: int64(min), max: int64(max)}
+}
+
+func secondsOption(min, max int64) *optionSpec {
+	return &optionSpec{kind: optionSeconds, min: min, max: max}
+}
+
+func enumOption(values ...string) *optionSpec {
+	return &optionSpec{kind: optionEnum, values: values}
+}
+
+// optionSpecs aria2 参数的类型定义,不在表中的参数按字符串处理
+// 参考 http://aria2.github.io/manual/en/html/aria2c.html#options
+var optionSpecs = map[string]*optionSpec{
+	"gid":                              {kind: optionGid},
+	"header":                           {kind: optionString, list: true},
+	"index-out":                        {kind: optionIndexOut, list: true},
+	"allow-overwrite":                  boolOption(),
+	"allow-piece-length-change":        boolOption(),
+	"always-resume":                    boolOption(),
+	"async-dns":                        boolOption(),
+	"auto-file-renaming":               boolOption(),
+	"bt-enable-hook-after-hash-check":  boolOption(),
+	"bt-enable-lpd":                    boolOption(),
+	"bt-force-encryption":              boolOption(),
+	"bt-hash-check-seed":               boolOption(),
+	"bt-load-saved-metadata":           boolOption(),
+	"bt-max-peers":                     intOption(0, -1),
+	"bt-metadata-only":                 boolOption(),
+	"bt-min-crypto-level":              enumOption("plain", "arc4"),
+	"bt-remove-unselected-file":        boolOption(),
+	"bt-request-peer-speed-limit":      sizeOption(0, -1),
+	"bt-require-crypto":                boolOption(),
+	"bt-save-metadata":                 boolOption(),
+	"bt-seed-unverified":               boolOption(),
+	"bt-stop-timeout":                  secondsOption(0, -1),
+	"bt-tracker-connect-timeout":       secondsOption(1, 600),
+	"bt-tracker-interval":              secondsOption(0, -1),
+	"bt-tracker-timeout":               secondsOption(1, 600),
+	"check-integrity":                  boolOption(),
+	"conditional-get":                  boolOption(),
+	"connect-timeout":                  secondsOption(1, 600),
+	"content-disposition-default-utf8": boolOption(),
+	"continue":                         boolOption(),
+	"dry-run":                          boolOption(),
+	"enable-http-keep-alive":           boolOption(),
+	"enable-http-pipelining":           boolOption(),
+	"enable-mmap":                      boolOption(),
+	"enable-peer-exchange":             boolOption(),
+	"file-allocation":                  enumOption("none", "prealloc", "trunc", "falloc"),
+	"follow-metalink":                  enumOption("true", "false", "mem"),
+	"follow-torrent":                   enumOption("true", "false", "mem"),
+	"force-save":                       boolOption(),
+	"ftp-pasv":                         boolOption(),
+	"ftp-reuse-connection":             boolOption(),
+	"ftp-type":                         enumOption("binary", "ascii"),
+	"hash-check-only":                  boolOption(),
+	"http-accept-gzip":                 boolOption(),
+	"http-auth-challenge":              boolOption(),
+	"http-no-cache":                    boolOption(),
+	"lowest-speed-limit":               sizeOption(0, -1),
+	"max-connection-per-server":        intOption(1, 16),
+	"max-download-limit":               sizeOption(0, -1),
+	"max-file-not-found":               intOption(0, -1),
+	"max-mmap-limit":                   sizeOption(0, -1),
+	"max-resume-failure-tries":         intOption(0, -1),
+	"max-tries":                        intOption(0, -1),
+	"max-upload-limit":                 sizeOption(0, -1),
+	"metalink-enable-unique-protocol":  boolOption(),
+	"metalink-preferred-protocol":      enumOption("http", "https", "ftp", "none"),
+	"min-split-size":                   sizeOption(MiB, 1024*MiB),
+	"no-file-allocation-limit":         sizeOption(0, -1),
+	"no-netrc":                         boolOption(),
+	"parameterized-uri":                boolOption(),
+	"pause":                            boolOption(),
+	"pause-metadata":                   boolOption(),
+	"piece-length":                     sizeOption(MiB, 1024*MiB),
+	"proxy-method":                     enumOption("get", "tunnel"),
+	"realtime-chunk-checksum":          boolOption(),
+	"remote-time":                      boolOption(),
+	"remove-control-file":              boolOption(),
+	"retry-wait":                       secondsOption(0, 600),
+	"reuse-uri":                        boolOption(),
+	"rpc-save-upload-metadata":         boolOption(),
+	"seed-ratio":                       {kind: optionFloat},
+	"seed-time":                        {kind: optionFloat},
+	"split":                            intOption(1, -1),
+	"stream-piece-selector":            enumOption("default", "inorder", "random", "geom"),
+	"timeout":                          secondsOption(1, 600),
+	"uri-selector":                     enumOption("inorder", "feedback", "adaptive"),
+	"use-head":                         boolOption(),
+
+	// 以下为只能通过 ChangeGlobalOption 设置的参数
+	"bt-max-open-files":               intOption(1, -1),
+	"download-result":                 enumOption("default", "full", "hide"),
+	"keep-unfinished-download-result": boolOption(),
+	"log-level":                       enumOption("debug", "info", "notice", "warn", "error"),
+	"max-concurrent-downloads":        intOption(1, -1),
+	"max-download-result":             intOption(0, -1),
+	"max-overall-download-limit":      sizeOption(0, -1),
+	"max-overall-upload-limit":        sizeOption(0, -1),
+}
+
+// OptionError 参数值错误
+type OptionError struct {
+	Key    string
+	Value  string
+	Reason string
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("aria2 option %s=%q: %s", e.Key, e.Value, e.Reason)
+}
+
+func (e *OptionError) Is(target error) bool {
+	return target == ErrInvalidOption
+}
+
+// isListOption 参数是否可以设置多个值
+func isListOption(key string) bool {
+	spec, ok := optionSpecs[key]
+	return ok && spec.list
+}
+
+// splitOptionValues 拆分以换行分隔的多个参数值
+func splitOptionValues(value string) []string {
+	values := make([]string, 0)
+	for _, item := range strings.Split(value, "\n") {
+		if strings.TrimSpace(item) != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// optionParamValue 转换为请求中的参数值,可以设置多个值的参数转换为数组
+func optionParamValue(key, value string) interface{} {
+	if isListOption(key) {
+		return splitOptionValues(value)
+	}
+	return value
+}
+
+// validateOption 按 optionSpecs 校验参数值
+func validateOption(key, value string) error {
+	spec, ok := optionSpecs[key]
+	if !ok {
+		return nil
+	}
+	if spec.list {
+		for _, item := range splitOptionValues(value) {
+			if err := validateOptionValue(key, item, spec); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return validateOptionValue(key, value, spec)
+}
+
+// validateOptionValue 校验单个参数值
+func validateOptionValue(key, value string, spec *optionSpec) error {
+	invalid := func(reason string, args ...interface{}) error {
+		return &OptionError{Key: key, Value: value, Reason: fmt.Sprintf(reason, args...)}
+	}
+
+	var number int64
+	switch spec.kind {
+	case optionBool:
+		if value != "true" && value != "false" {
+			return invalid("must be true or false")
+		}
+		return nil
+	case optionEnum:
+		for _, item := range spec.values {
+			if value == item {
+				return nil
+			}
+		}
+		return invalid("must be one of %s", strings.Join(spec.values, ", "))
+	case optionFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+			return invalid("must be a non-negative finite number")
+		}
+		return nil
+	case optionIndexOut:
+		index, path, found := strings.Cut(value, "=")
+		if n, err := strconv.Atoi(index); !found || err != nil || n < 1 || path == "" {
+			return invalid("must be INDEX=PATH, INDEX starts from 1")
+		}
+		return nil
+	case optionGid:
+		if len(value) != 16 || strings.Trim(strings.ToLower(value), "0123456789abcdef") != "" {
+			return invalid("must be 16 hex characters")
+		}
+		return nil
+	case optionInt, optionSeconds:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return invalid("must be an integer")
+		}
+		number = n
+	case optionSize:
+		size, err := ParseByteSize(value)
+		if err != nil {
+			return invalid("must be a byte size such as 1024, 512K or 1M")
+		}
+		number = int64(size)
+	default:
+		return nil
+	}
+
+	if number < spec.min || (spec.max >= 0 && number > spec.max) {
+		if spec.max < 0 {
+			return invalid("must be greater than or equal to %d", spec.min)
+		}
+		return invalid("must be between %d and %d", spec.min, spec.max)
+	}
+	return nil
+}
+
+var (
+	optionFieldOnce  sync.Once
+	optionFieldIndex map[string]int
+)
+
+// getOptionFieldIndex Option 字段的 json 名称到字段序号的映射
+func getOptionFieldIndex() map[string]int {
+	optionFieldOnce.Do(func() {
+		t := reflect.TypeOf(Option{})
+		optionFieldIndex = make(map[string]int, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			optionFieldIndex[t.Field(i).Tag.Get("json")] = i
+		}
+	})
+	return optionFieldIndex
+}
+
+// AddHeader 添加 HTTP 请求头,多个请求头在 Header 中以换行分隔
+func (o *Option) AddHeader(header string) {
+	o.Header = appendOptionValue(o.Header, header)
+}
+
+// AddIndexOut 设置 BitTorrent 任务中第 index 个文件的保存路径,index 从 1 开始
+func (o *Option) AddIndexOut(index int, path string) {
+	o.IndexOut = appendOptionValue(o.IndexOut, strconv.Itoa(index)+"="+path)
+}
+
+func appendOptionValue(current, value string) string {
+	if current == "" {
+		return value
+	}
+	return current + "\n" + value
+}
+
+// OptionMap GetOption GetGlobalOption 返回的参数
+// 可以设置多个值的参数以换行分隔,使用 Values 获取
+type OptionMap map[string]string
+
+// Get 获取参数值
+func (m OptionMap) Get(key string) string {
+	return m[key]
+}
+
+// Values 获取参数的所有值,用于 header index-out 等可以设置多个值的参数
+func (m OptionMap) Values(key string) []string {
+	value, ok := m[key]
+	if !ok {
+		return nil
+	}
+	if isListOption(key) {
+		return splitOptionValues(value)
+	}
+	return []string{value}
+}
+
+// Option 转换为 Option,Option 中不存在的参数会被忽略
+func (m OptionMap) Option() *Option {
+	option := &Option{}
+	v := reflect.ValueOf(option).Elem()
+	index := getOptionFieldIndex()
+	for key, value := range m {
+		if i, ok := index[key]; ok {
+			v.Field(i).SetString(value)
+		}
+	}
+	return option
+}
+
+// UnmarshalJSON 兼容参数值为数组的情况
+func (m *OptionMap) UnmarshalJSON(data []byte) error {
+	raw := make(map[string]interface{})
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	result := make(OptionMap, len(raw))
+	for key, value := range raw {
+		switch item := value.(type) {
+		case string:
+			result[key] = item
+		case []interface{}:
+			values := make([]string, 0, len(item))
+			for _, v := range item {
+				values = append(values, fmt.Sprint(v))
+			}
+			result[key] = strings.Join(values, "\n")
+		default:
+			result[key] = fmt.Sprint(item)
+		}
+	}
+	*m = result
+	return nil
+}
+
+// Validate 校验所有已设置的参数值
+func (o *Option) Validate() error {
+	v := reflect.ValueOf(*o)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		value := v.Field(i).String()
+		if value == "" {
+			continue
+		}
+		if err := validateOption(t.Field(i).Tag.Get("json"), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OptionBuilder 类型化的参数构造器
+// 每个参数在设置时校验,第一个错误会在 Build 时返回
+//
+//	option, err := aria2go.NewOptionBuilder().
+//		Dir("/data").
+//		Split(8).
+//		MaxDownloadLimit(512 * aria2go.KiB).
+//		FileAllocation(aria2go.FILE_ALLOCATION_FALLOC).
+//		Build()
+type OptionBuilder struct {
+	values    map[string]string
+	errorInfo error
+}
+
+// NewOptionBuilder 创建参数构造器
+func NewOptionBuilder() *OptionBuilder {
+	return &OptionBuilder{values: make(map[string]string)}
+}
+
+// checkKind 校验参数是否为指定类型
+func (o *OptionBuilder) checkKind(key string, kinds ...optionKind) error {
+	spec, ok := optionSpecs[key]
+	kind := optionString
+	if ok {
+		kind = spec.kind
+	}
+	for _, item := range kinds {
+		if kind == item {
+			return nil
+		}
+	}
+	return &OptionError{Key: key, Reason: "option type mismatch"}
+}
+
+// Set 设置任意参数,会按参数类型校验
+func (o *OptionBuilder) Set(key, value string) *OptionBuilder {
+	if o.errorInfo != nil {
+		return o
+	}
+	if err := validateOption(key, value); err != nil {
+		o.errorInfo = err
+		return o
+	}
+	o.values[key] = value
+	return o
+}
+
+// Bool 设置布尔类型的参数
+func (o *OptionBuilder) Bool(key string, value bool) *OptionBuilder {
+	if o.errorInfo != nil {
+		return o
+	}
+	if err := o.checkKind(key, optionBool); err != nil {
+		o.errorInfo = err
+		return o
+	}
+	return o.Set(key, strconv.FormatBool(value))
+}
+
+// Int 设置整数类型的参数
+func (o *OptionBuilder) Int(key string, value int64) *OptionBuilder {
+	if o.errorInfo != nil {
+		return o
+	}
+	if err := o.checkKind(key, optionInt); err != nil {
+		o.errorInfo = err
+		return o
+	}
+	return o.Set(key, strconv.FormatInt(value, 10))
+}
+
+// Size 设置字节大小类型的参数
+func (o *OptionBuilder) Size(key string, value ByteSize) *OptionBuilder {
+	if o.errorInfo != nil {
+		return o
+	}
+	if err := o.checkKind(key, optionSize); err != nil {
+		o.errorInfo = err
+		return o
+	}
+	return o.Set(key, value.String())
+}
+
+// Seconds 设置以秒为单位的参数,value 必须是整数秒
+func (o *OptionBuilder) Seconds(key string, value time.Duration) *OptionBuilder {
+	if o.errorInfo != nil {
+		return o
+	}
+	if err := o.checkKind(key, optionSeconds); err != nil {
+		o.errorInfo = err
+		return o
+	}
+	if value%time.Second != 0 {
+		o.errorInfo = &OptionError{Key: key, Value: value.String(), Reason: "must be whole seconds"}
+		return o
+	}
+	return o.Set(key, strconv.FormatInt(int64(value/time.Second), 10))
+}
+
+// Add 为可以设置多个值的参数添加一个值,例如 header index-out
+func (o *OptionBuilder) Add(key, value string) *OptionBuilder {
+	if o.errorInfo != nil {
+		return o
+	}
+	if !isListOption(key) {
+		o.errorInfo = &OptionError{Key: key, Value: value, Reason: "option does not accept multiple values"}
+		return o
+	}
+	if strings.Contains(value, "\n") {
+		o.errorInfo = &OptionError{Key: key, Value: value, Reason: "value must not contain newline"}
+		return o
+	}
+	if err := validateOption(key, value); err != nil {
+		o.errorInfo = err
+		return o
+	}
+	if current := o.values[key]; current != "" {
+		value = current + "\n" + value
+	}
+	o.values[key] = value
+	return o
+}
+
+// Header 添加 HTTP 请求头,可以多次调用
+//
+//	builder.Header("Authorization: Bearer xxx").Header("Cookie: a=b")
+func (o *OptionBuilder) Header(headers ...string) *OptionBuilder {
+	for _, header := range headers {
+		o.Add("header", header)
+	}
+	return o
+}
+
+// IndexOut 设置 BitTorrent 任务中第 index 个文件的保存路径,index 从 1 开始,可以多次调用
+func (o *OptionBuilder) IndexOut(index int, path string) *OptionBuilder {
+	return o.Add("index-out", strconv.Itoa(index)+"="+path)
+}
+
+// Dir 下载目录
+func (o *OptionBuilder) Dir(dir string) *OptionBuilder {
+	return o.Set("dir", dir)
+}
+
+// Out 下载文件名
+func (o *OptionBuilder) Out(out string) *OptionBuilder {
+	return o.Set("out", out)
+}
+
+// Gid 指定任务 gid,16 位十六进制字符串
+func (o *OptionBuilder) Gid(gid string) *OptionBuilder {
+	return o.Set("gid", gid)
+}
+
+// Split 单个文件的连接数
+func (o *OptionBuilder) Split(n int64) *OptionBuilder {
+	return o.Int("split", n)
+}
+
+// MaxConnectionPerServer 每个服务器的最大连接数,范围 1-16
+func (o *OptionBuilder) MaxConnectionPerServer(n int64) *OptionBuilder {
+	return o.Int("max-connection-per-server", n)
+}
+
+// MinSplitSize 最小分片大小,范围 1M-1024M
+func (o *OptionBuilder) MinSplitSize(size ByteSize) *OptionBuilder {
+	return o.Size("min-split-size", size)
+}
+
+// MaxTries 最大重试次数,0 为不限制
+func (o *OptionBuilder) MaxTries(n int64) *OptionBuilder {
+	return o.Int("max-tries", n)
+}
+
+// MaxDownloadLimit 单个任务最大下载速度,0 为不限制
+func (o *OptionBuilder) MaxDownloadLimit(size ByteSize) *OptionBuilder {
+	return o.Size("max-download-limit", size)
+}
+
+// MaxUploadLimit 单个任务最大上传速度,0 为不限制
+func (o *OptionBuilder) MaxUploadLimit(size ByteSize) *OptionBuilder {
+	return o.Size("max-upload-limit", size)
+}
+
+// LowestSpeedLimit 下载速度低于该值时断开连接,0 为不限制
+func (o *OptionBuilder) LowestSpeedLimit(size ByteSize) *OptionBuilder {
+	return o.Size("lowest-speed-limit", size)
+}
+
+// Timeout 超时时间,范围 1-600 秒
+func (o *OptionBuilder) Timeout(timeout time.Duration) *OptionBuilder {
+	return o.Seconds("timeout", timeout)
+}
+
+// ConnectTimeout 连接超时时间,范围 1-600 秒
+func (o *OptionBuilder) ConnectTimeout(timeout time.Duration) *OptionBuilder {
+	return o.Seconds("connect-timeout", timeout)
+}
+
+// RetryWait 重试间隔,范围 0-600 秒
+func (o *OptionBuilder) RetryWait(wait time.Duration) *OptionBuilder {
+	return o.Seconds("retry-wait", wait)
+}
+
+// FileAllocation 文件预分配方式
+func (o *OptionBuilder) FileAllocation(value FileAllocation) *OptionBuilder {
+	return o.Set("file-allocation", string(value))
+}
+
+// UriSelector 下载源选择算法
+func (o *OptionBuilder) UriSelector(value UriSelector) *OptionBuilder {
+	return o.Set("uri-selector", string(value))
+}
+
+// StreamPieceSelector HTTP/FTP 下载的分片选择算法
+func (o *OptionBuilder) StreamPieceSelector(value StreamPieceSelector) *OptionBuilder {
+	return o.Set("stream-piece-selector", string(value))
+}
+
+// FTPType FTP 传输类型
+func (o *OptionBuilder) FTPType(value FTPType) *OptionBuilder {
+	return o.Set("ftp-type", string(value))
+}
+
+// ProxyMethod 代理请求方式
+func (o *OptionBuilder) ProxyMethod(value ProxyMethod) *OptionBuilder {
+	return o.Set("proxy-method", string(value))
+}
+
+// AllowOverwrite 文件已存在时是否重新下载
+func (o *OptionBuilder) AllowOverwrite(value bool) *OptionBuilder {
+	return o.Bool("allow-overwrite", value)
+}
+
+// AutoFileRenaming 文件已存在时是否自动重命名
+func (o *OptionBuilder) AutoFileRenaming(value bool) *OptionBuilder {
+	return o.Bool("auto-file-renaming", value)
+}
+
+// Continue 是否继续下载部分下载的文件
+func (o *OptionBuilder) Continue(value bool) *OptionBuilder {
+	return o.Bool("continue", value)
+}
+
+// CheckIntegrity 是否校验文件完整性
+func (o *OptionBuilder) CheckIntegrity(value bool) *OptionBuilder {
+	return o.Bool("check-integrity", value)
+}
+
+// Pause 添加后是否暂停任务
+func (o *OptionBuilder) Pause(value bool) *OptionBuilder {
+	return o.Bool("pause", value)
+}
+
+// BTMaxPeers BitTorrent 任务最大 peer 数量,0 为不限制
+func (o *OptionBuilder) BTMaxPeers(n int64) *OptionBuilder {
+	return o.Int("bt-max-peers", n)
+}
+
+// SeedRatio 做种分享率,达到后停止做种,0 为一直做种
+func (o *OptionBuilder) SeedRatio(ratio float64) *OptionBuilder {
+	return o.Set("seed-ratio", strconv.FormatFloat(ratio, 'f', -1, 64))
+}
+
+// SeedTime 做种时间,aria2 以分钟为单位
+func (o *OptionBuilder) SeedTime(seedTime time.Duration) *OptionBuilder {
+	return o.Set("seed-time", strconv.FormatFloat(seedTime.Minutes(), 'f', -1, 64))
+}
+
+// BuildMap 生成参数 map,可以用于 ChangeGlobalOption 的 otherOpt
+// 可以设置多个值的参数以换行分隔
+func (o *OptionBuilder) BuildMap() (map[string]string, error) {
+	if o.errorInfo != nil {
+		return nil, o.errorInfo
+	}
+	result := make(map[string]string, len(o.values))
+	for key, value := range o.values {
+		result[key] = value
+	}
+	return result, nil
+}
+
+// Build 生成 Option
+// 设置了 Option 中不存在的参数时返回错误,这种情况使用 BuildMap
+func (o *OptionBuilder) Build() (*Option, error) {
+	if o.errorInfo != nil {
+		return nil, o.errorInfo
+	}
+
+	option := &Option{}
+	v := reflect.ValueOf(option).Elem()
+	index := getOptionFieldIndex()
+	for key, value := range o.values {
+		i, ok := index[key]
+		if !ok {
+			return nil, &OptionError{Key: key, Value: value, Reason: "not supported by Option, use BuildMap"}
+		}
+		v.Field(i).SetString(value)
+	}
+	return option, nil
+}
@@ -0,0 +1,134 @@
+package aria2go
+
+import (
+	"reflect"
+	"sort"
+)
+
+// OptionScope 参数的使用范围
+type OptionScope int
+
+const (
+	// OPTION_SCOPE_INPUT_FILE AddUri AddTorrent 等添加任务时可以使用的参数
+	OPTION_SCOPE_INPUT_FILE OptionScope = iota
+	// OPTION_SCOPE_CHANGE ChangeOption 可以修改的参数
+	OPTION_SCOPE_CHANGE
+	// OPTION_SCOPE_GLOBAL ChangeGlobalOption 可以修改的参数
+	OPTION_SCOPE_GLOBAL
+)
+
+func (s OptionScope) String() string {
+	switch s {
+	case OPTION_SCOPE_INPUT_FILE:
+		return "input file"
+	case OPTION_SCOPE_CHANGE:
+		return "aria2.changeOption"
+	case OPTION_SCOPE_GLOBAL:
+		return "aria2.changeGlobalOption"
+	}
+	return "unknown"
+}
+
+// inputFileOptions 添加任务时可以使用的参数
+// 参考 http://aria2.github.io/manual/en/html/aria2c.html#input-file
+var inputFileOptions = newOptionSet(
+	"all-proxy", "all-proxy-passwd", "all-proxy-user", "allow-overwrite", "allow-piece-length-change",
+	"always-resume", "async-dns", "auto-file-renaming", "bt-enable-hook-after-hash-check", "bt-enable-lpd",
+	"bt-exclude-tracker", "bt-external-ip", "bt-force-encryption", "bt-hash-check-seed", "bt-load-saved-metadata",
+	"bt-max-peers", "bt-metadata-only", "bt-min-crypto-level", "bt-prioritize-piece", "bt-remove-unselected-file",
+	"bt-request-peer-speed-limit", "bt-require-crypto", "bt-save-metadata", "bt-seed-unverified", "bt-stop-timeout",
+	"bt-tracker", "bt-tracker-connect-timeout", "bt-tracker-interval", "bt-tracker-timeout", "check-integrity",
+	"checksum", "conditional-get", "connect-timeout", "content-disposition-default-utf8", "continue", "dir",
+	"dry-run", "enable-http-keep-alive", "enable-http-pipelining", "enable-mmap", "enable-peer-exchange",
+	"file-allocation", "follow-metalink", "follow-torrent", "force-save", "ftp-passwd", "ftp-pasv", "ftp-proxy",
+	"ftp-proxy-passwd", "ftp-proxy-user", "ftp-reuse-connection", "ftp-type", "ftp-user", "gid", "hash-check-only",
+	"header", "http-accept-gzip", "http-auth-challenge", "http-no-cache", "http-passwd", "http-proxy",
+	"http-proxy-passwd", "http-proxy-user", "http-user", "https-proxy", "https-proxy-passwd", "https-proxy-user",
+	"index-out", "lowest-speed-limit", "max-connection-per-server", "max-download-limit", "max-file-not-found",
+	"max-mmap-limit", "max-resume-failure-tries", "max-tries", "max-upload-limit", "metalink-base-uri",
+	"metalink-enable-unique-protocol", "metalink-language", "metalink-location", "metalink-os",
+	"metalink-preferred-protocol", "metalink-version", "min-split-size", "no-file-allocation-limit", "no-netrc",
+	"no-proxy", "out", "parameterized-uri", "pause", "pause-metadata", "piece-length", "proxy-method",
+	"realtime-chunk-checksum", "referer", "remote-time", "remove-control-file", "retry-wait", "reuse-uri",
+	"rpc-save-upload-metadata", "seed-ratio", "seed-time", "select-file", "split", "ssh-host-key-md",
+	"stream-piece-selector", "timeout", "uri-selector", "use-head", "user-agent",
+)
+
+// changeOptionExcluded ChangeOption 不能修改的 input file 参数
+var changeOptionExcluded = newOptionSet(
+	"dry-run", "metalink-base-uri", "parameterized-uri", "pause", "piece-length", "rpc-save-upload-metadata",
+)
+
+// noRestartOptions ChangeOption 修改正在下载的任务时,不会使任务重新启动的参数
+var noRestartOptions = newOptionSet(
+	"bt-max-peers", "bt-request-peer-speed-limit", "bt-remove-unselected-file", "force-save",
+	"max-download-limit", "max-upload-limit",
+)
+
+// globalOnlyOptions 只能通过 ChangeGlobalOption 修改的参数
+var globalOnlyOptions = newOptionSet(
+	"bt-max-open-files", "download-result", "keep-unfinished-download-result", "log", "log-level",
+	"max-concurrent-downloads", "max-download-result", "max-overall-download-limit", "max-overall-upload-limit",
+	"optimize-concurrent-downloads", "save-cookies", "save-session", "server-stat-of",
+)
+
+// globalExcluded ChangeGlobalOption 不能修改的 input file 参数
+var globalExcluded = newOptionSet(
+	"checksum", "index-out", "out", "pause", "select-file",
+)
+
+type optionSet map[string]bool
+
+func newOptionSet(keys ...string) optionSet {
+	set := make(optionSet, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// OptionAllowed 参数是否可以在 scope 中使用
+func OptionAllowed(scope OptionScope, key string) bool {
+	switch scope {
+	case OPTION_SCOPE_INPUT_FILE:
+		return inputFileOptions[key]
+	case OPTION_SCOPE_CHANGE:
+		return inputFileOptions[key] && !changeOptionExcluded[key]
+	case OPTION_SCOPE_GLOBAL:
+		return globalOnlyOptions[key] || (inputFileOptions[key] && !globalExcluded[key])
+	}
+	return false
+}
+
+// OptionRequiresRestart 通过 ChangeOption 修改正在下载的任务的参数时,任务是否会重新启动
+// 重启由 aria2 自行执行,BitTorrent 任务重启会中断做种
+func OptionRequiresRestart(key string) bool {
+	return OptionAllowed(OPTION_SCOPE_CHANGE, key) && !noRestartOptions[key]
+}
+
+// RestartKeys 返回 Option 中通过 ChangeOption 修改时会使正在下载的任务重新启动的参数
+func (o *Option) RestartKeys() []string {
+	keys := make([]string, 0)
+	v := reflect.ValueOf(*o)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("json")
+		if v.Field(i).String() != "" && OptionRequiresRestart(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkOptionScope 校验参数是否可以在 scope 中使用
+func checkOptionScope(scope OptionScope, key, value string) error {
+	if OptionAllowed(scope, key) {
+		return nil
+	}
+	reason := "not allowed in " + scope.String()
+	if key == "position" {
+		reason += ", use the position argument of AddUri AddTorrent instead"
+	}
+	return &OptionError{Key: key, Value: value, Reason: reason}
+}
@@ -0,0 +1,137 @@
+package aria2go
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOptionBuilder(t *testing.T) {
+	option, err := NewOptionBuilder().
+		Dir("/data").
+		Split(8).
+		MaxDownloadLimit(512 * KiB).
+		MinSplitSize(20 * MiB).
+		Timeout(30 * time.Second).
+		FileAllocation(FILE_ALLOCATION_FALLOC).
+		AllowOverwrite(true).
+		SeedTime(90 * time.Minute).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if option.Dir != "/data" || option.Split != "8" || option.MaxDownloadLimit != "512K" || option.MinSplitSize != "20M" ||
+		option.Timeout != "30" || option.FileAllocation != "falloc" || option.AllowOverwrite != "true" || option.SeedTime != "90" {
+		t.Errorf("unexpected option %#v", option)
+	}
+
+	invalid := []*OptionBuilder{
+		NewOptionBuilder().MaxConnectionPerServer(32),
+		NewOptionBuilder().MinSplitSize(512 * KiB),
+		NewOptionBuilder().Timeout(1500 * time.Millisecond),
+		NewOptionBuilder().FileAllocation("fast"),
+		NewOptionBuilder().Set("allow-overwrite", "ture"),
+		NewOptionBuilder().Bool("split", true),
+		NewOptionBuilder().Gid("xyz"),
+		NewOptionBuilder().Set("seed-ratio", "NaN"),
+		NewOptionBuilder().Set("seed-ratio", "Inf"),
+		NewOptionBuilder().Set("seed-time", "+Inf"),
+	}
+	for _, builder := range invalid {
+		if _, err := builder.Build(); !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("want invalid option got %v", err)
+		}
+	}
+
+	if _, err := NewOptionBuilder().Int("max-concurrent-downloads", 5).Build(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("want unsupported option error got %v", err)
+	}
+	values, err := NewOptionBuilder().Int("max-concurrent-downloads", 5).BuildMap()
+	if err != nil || values["max-concurrent-downloads"] != "5" {
+		t.Errorf("unexpected map %v %v", values, err)
+	}
+
+	_, _, err = NewRequestWithToken("thanks").AddUri([]string{"http://a/b"}, &Option{MaxDownloadLimit: "1 MB"}).Create()
+	if !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("want invalid option got %v", err)
+	}
+}
+
+func TestListOption(t *testing.T) {
+	option, err := NewOptionBuilder().
+		Header("Authorization: Bearer token", "Cookie: a=b").
+		IndexOut(1, "a.mkv").
+		IndexOut(3, "c.mkv").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := NewRequestWithToken("thanks").AddUri([]string{"http://a/b"}, option)
+	if _, _, err := request.Create(); err != nil {
+		t.Fatal(err)
+	}
+	params := request.Params[2].(map[string]interface{})
+	headers := params["header"].([]string)
+	indexOut := params["index-out"].([]string)
+	if len(headers) != 2 || headers[1] != "Cookie: a=b" || len(indexOut) != 2 || indexOut[1] != "3=c.mkv" {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	if _, err := NewOptionBuilder().IndexOut(0, "a.mkv").Build(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("want invalid option got %v", err)
+	}
+	if _, err := NewOptionBuilder().Add("dir", "/data").Build(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("want invalid option got %v", err)
+	}
+
+	options := OptionMap{}
+	if err := options.UnmarshalJSON([]byte(`{"header":["A: 1","B: 2"],"index-out":"1=a.mkv\n2=b.mkv","dir":"/data"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if values := options.Values("header"); len(values) != 2 || values[1] != "B: 2" {
+		t.Errorf("unexpected header %v", values)
+	}
+	if values := options.Values("index-out"); len(values) != 2 || values[0] != "1=a.mkv" {
+		t.Errorf("unexpected index-out %v", values)
+	}
+	if options.Option().Dir != "/data" {
+		t.Errorf("unexpected option %#v", options.Option())
+	}
+}
+
+func TestOptionScope(t *testing.T) {
+	if _, _, err := NewRequestWithToken("thanks").ChangeOption("2089b05ecca3d829", &Option{Pause: "true"}).Create(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("want invalid option got %v", err)
+	}
+	if _, _, err := NewRequestWithToken("thanks").ChangeGlobalOption(&Option{Out: "a.mkv"}).Create(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("want invalid option got %v", err)
+	}
+	if _, _, err := NewRequestWithToken("thanks").AddUri([]string{"http://a/b"}, &Option{Position: "1"}).Create(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("want invalid option got %v", err)
+	}
+	if _, _, err := NewRequestWithToken("thanks").ChangeGlobalOption(nil, map[string]string{"max-concurrent-downloads": "3"}).Create(); err != nil {
+		t.Error(err)
+	}
+	if _, _, err := NewRequestWithToken("thanks").ChangeOption("2089b05ecca3d829", &Option{MaxDownloadLimit: "1M", Dir: "/data"}).Create(); err != nil {
+		t.Error(err)
+	}
+	request := NewRequestWithToken("thanks").ChangeOption("2089b05ecca3d829", nil, map[string]string{"split": "4"})
+	if _, _, err := request.Create(); err != nil || request.Params[2].(map[string]interface{})["split"] != "4" {
+		t.Errorf("ChangeOption otherOpt %v %v", request.Params, err)
+	}
+	if _, _, err := NewRequestWithToken("thanks").ChangeOption("2089b05ecca3d829", nil, map[string]string{"pause": "true"}).Create(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("want invalid option got %v", err)
+	}
+
+	if OptionAllowed(OPTION_SCOPE_INPUT_FILE, "max-concurrent-downloads") || !OptionAllowed(OPTION_SCOPE_GLOBAL, "dir") {
+		t.Error("unexpected option scope")
+	}
+	if OptionRequiresRestart("max-download-limit") || !OptionRequiresRestart("split") {
+		t.Error("unexpected restart option")
+	}
+	keys := (&Option{MaxDownloadLimit: "1M", Split: "4", Dir: "/data"}).RestartKeys()
+	if len(keys) != 2 || keys[0] != "dir" || keys[1] != "split" {
+		t.Errorf("unexpected restart keys %v", keys)
+	}
+}
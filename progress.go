@@ -0,0 +1,164 @@
+package aria2go
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DEFAULT_PROGRESS_SMOOTHING 下载速度指数平滑系数,越大越接近当前速度
+const DEFAULT_PROGRESS_SMOOTHING = 0.3
+
+// ProgressUpdate 任务进度
+type ProgressUpdate struct {
+	Gid             string
+	Status          TaskStatus
+	TotalLength     int64
+	CompletedLength int64
+	// DownloadSpeed UploadSpeed 指数平滑后的速度,单位 byte/s
+	DownloadSpeed float64
+	UploadSpeed   float64
+	// ETA 按平滑后的下载速度计算的剩余时间,速度为 0 或总大小未知时为 -1
+	ETA time.Duration
+	// Percent 下载进度,范围 0-100
+	Percent float64
+	// Time 查询时间
+	Time time.Time
+	// Err 查询失败时的错误,任务不存在时该任务不再更新
+	Err error
+}
+
+// Done 任务是否已经结束
+func (p *ProgressUpdate) Done() bool {
+	switch p.Status {
+	case STATUS_COMPLETE, STATUS_ERROR, STATUS_REMOVED:
+		return true
+	}
+	return false
+}
+
+// WatchProgress 每隔 interval 查询一次任务进度,通过 channel 返回
+// 每次查询通过一次 system.multicall 查询所有任务,只返回计算进度需要的字段
+// 任务结束后发送最后一次进度并停止查询,所有任务结束或 ctx 结束后关闭 channel
+// 接收方需要持续读取 channel,否则下一次查询会等待
+func (a Aria2Client) WatchProgress(ctx context.Context, interval time.Duration, gids ...string) (<-chan *ProgressUpdate, error) {
+	if len(gids) < 1 {
+		return nil, errors.New("gid is required")
+	}
+	if interval <= 0 {
+		return nil, errors.New("interval must be greater than 0")
+	}
+
+	watcher := &progressWatcher{
+		client:   &a,
+		interval: interval,
+		gids:     append([]string(nil), gids...),
+		last:     make(map[string]*ProgressUpdate, len(gids)),
+		out:      make(chan *ProgressUpdate),
+	}
+	go watcher.run(ctx)
+	return watcher.out, nil
+}
+
+// progressWatcher 查询任务进度并计算平滑速度
+type progressWatcher struct {
+	client   *Aria2Client
+	interval time.Duration
+	gids     []string
+	last     map[string]*ProgressUpdate
+	out      chan *ProgressUpdate
+}
+
+func (w *progressWatcher) run(ctx context.Context) {
+	defer close(w.out)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		updates := w.poll(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		active := make([]string, 0, len(w.gids))
+		for _, update := range updates {
+			select {
+			case w.out <- update:
+			case <-ctx.Done():
+				return
+			}
+			if !update.Done() && !errors.Is(update.Err, ErrGIDNotFound) {
+				active = append(active, update.Gid)
+			}
+		}
+		w.gids = active
+		if len(w.gids) == 0 {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// poll 通过一次 system.multicall 查询所有任务的进度
+func (w *progressWatcher) poll(ctx context.Context) []*ProgressUpdate {
+	now := time.Now()
+	batch := w.client.NewMultiCallBatch()
+	results := make([]*MultiCallResult[*TaskStatusData], 0, len(w.gids))
+	for _, gid := range w.gids {
+		request := NewRequestWithToken(w.client.Token).TellStatus(gid, FIELDS_PROGRESS.Keys()...)
+		results = append(results, AddMultiCall[*TaskStatusData](batch, request))
+	}
+	// 整个请求失败时错误记录在每个结果中
+	_ = batch.SendContext(ctx)
+
+	updates := make([]*ProgressUpdate, 0, len(w.gids))
+	for i, gid := range w.gids {
+		update := &ProgressUpdate{Gid: gid, Time: now, ETA: -1}
+		if results[i].Err != nil {
+			update.Err = results[i].Err
+			updates = append(updates, update)
+			continue
+		}
+		stats, err := results[i].Result.Stats()
+		if err != nil {
+			update.Err = err
+			updates = append(updates, update)
+			continue
+		}
+		w.apply(update, stats)
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// apply 根据查询结果和上一次的进度计算平滑速度 ETA 和进度
+func (w *progressWatcher) apply(update *ProgressUpdate, stats *TaskStats) {
+	update.Status = stats.Status
+	update.TotalLength = stats.TotalLength
+	update.CompletedLength = stats.CompletedLength
+	update.DownloadSpeed = float64(stats.DownloadSpeed)
+	update.UploadSpeed = float64(stats.UploadSpeed)
+	if last, ok := w.last[update.Gid]; ok {
+		update.DownloadSpeed = smoothSpeed(last.DownloadSpeed, update.DownloadSpeed)
+		update.UploadSpeed = smoothSpeed(last.UploadSpeed, update.UploadSpeed)
+	}
+	w.last[update.Gid] = update
+
+	update.Percent = stats.Progress() * 100
+	remaining := stats.TotalLength - stats.CompletedLength
+	switch {
+	case stats.TotalLength > 0 && remaining <= 0:
+		update.ETA = 0
+	case stats.TotalLength > 0 && update.DownloadSpeed > 0:
+		update.ETA = time.Duration(float64(remaining) / update.DownloadSpeed * float64(time.Second))
+	}
+}
+
+func smoothSpeed(last, current float64) float64 {
+	return DEFAULT_PROGRESS_SMOOTHING*current + (1-DEFAULT_PROGRESS_SMOOTHING)*last
+}
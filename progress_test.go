@@ -0,0 +1,75 @@
+package aria2go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchProgress(t *testing.T) {
+	requests := 0
+	speeds := []string{"100", "200", "0"}
+	progressClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &RequestBody{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		if request.Method != "system.multicall" {
+			t.Errorf("unexpected method %s", request.Method)
+		}
+
+		results := make([]string, 0)
+		for _, item := range request.Params[0].([]interface{}) {
+			params := item.(map[string]interface{})["params"].([]interface{})
+			switch params[1] {
+			case "2089b05ecca3d829":
+				status, completed := "active", (requests+1)*250
+				if requests == 2 {
+					status = "complete"
+				}
+				results = append(results, fmt.Sprintf(`[{"gid":"2089b05ecca3d829","status":"%s","totalLength":"750","completedLength":"%d","downloadSpeed":"%s","uploadSpeed":"0"}]`,
+					status, completed, speeds[requests]))
+			default:
+				results = append(results, `{"code":1,"message":"GID d2703803b52216d1 is not found"}`)
+			}
+		}
+		requests++
+		_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","result":[%s]}`, request.ReplayID, strings.Join(results, ","))
+	}))
+
+	updates, err := progressClient.WatchProgress(context.Background(), time.Millisecond, "2089b05ecca3d829", "d2703803b52216d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make([]*ProgressUpdate, 0)
+	for update := range updates {
+		if update.Gid == "d2703803b52216d1" {
+			if !errors.Is(update.Err, ErrGIDNotFound) {
+				t.Errorf("want gid not found got %v", update.Err)
+			}
+			continue
+		}
+		if update.Err != nil {
+			t.Fatal(update.Err)
+		}
+		received = append(received, update)
+	}
+
+	if len(received) != 3 || requests != 3 {
+		t.Fatalf("want 3 updates got %d in %d requests", len(received), requests)
+	}
+	// 平滑速度 100 -> 0.3*200+0.7*100 = 130
+	if received[1].DownloadSpeed != 130 || received[1].Percent < 66 || received[1].Percent > 67 {
+		t.Errorf("unexpected update %#v", received[1])
+	}
+	if eta := received[1].ETA; eta < 1900*time.Millisecond || eta > 1950*time.Millisecond {
+		t.Errorf("unexpected eta %s", received[1].ETA)
+	}
+	if !received[2].Done() || received[2].ETA != 0 || received[2].Percent != 100 {
+		t.Errorf("unexpected last update %#v", received[2])
+	}
+}
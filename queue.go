@@ -0,0 +1,204 @@
+package aria2go
+
+import (
+	"context"
+	"fmt"
+)
+
+// queuePageSize 查询等待队列时每次请求的任务数量
+const queuePageSize = 1000
+
+// WaitingGids 按队列顺序返回等待队列中所有任务的 gid
+func (a Aria2Client) WaitingGids() (gids []string, err error) {
+	return a.WaitingGidsContext(context.Background())
+}
+
+// WaitingGidsContext 同 WaitingGids,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) WaitingGidsContext(ctx context.Context) (gids []string, err error) {
+	gids = make([]string, 0)
+	iter := a.IterWaitingContext(ctx, 0, queuePageSize, "gid")
+	for iter.Next() {
+		gids = append(gids, iter.Task().Gid)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return gids, nil
+}
+
+// MoveToFront 将任务移动到等待队列的最前面,返回移动后的位置
+func (a Aria2Client) MoveToFront(gid string) (position int, err error) {
+	return a.MoveToFrontContext(context.Background(), gid)
+}
+
+// MoveToFrontContext 同 MoveToFront,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) MoveToFrontContext(ctx context.Context, gid string) (position int, err error) {
+	return a.ChangePositionContext(ctx, gid, 0, POS_SET)
+}
+
+// MoveToBack 将任务移动到等待队列的最后面,返回移动后的位置
+func (a Aria2Client) MoveToBack(gid string) (position int, err error) {
+	return a.MoveToBackContext(context.Background(), gid)
+}
+
+// MoveToBackContext 同 MoveToBack,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) MoveToBackContext(ctx context.Context, gid string) (position int, err error) {
+	return a.ChangePositionContext(ctx, gid, 0, POS_END)
+}
+
+// MoveBefore 将任务移动到等待队列中 target 的前面,返回移动后的位置
+func (a Aria2Client) MoveBefore(gid, target string) (position int, err error) {
+	return a.MoveBeforeContext(context.Background(), gid, target)
+}
+
+// MoveBeforeContext 同 MoveBefore,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) MoveBeforeContext(ctx context.Context, gid, target string) (position int, err error) {
+	return a.moveRelative(ctx, gid, target, 0)
+}
+
+// MoveAfter 将任务移动到等待队列中 target 的后面,返回移动后的位置
+func (a Aria2Client) MoveAfter(gid, target string) (position int, err error) {
+	return a.MoveAfterContext(context.Background(), gid, target)
+}
+
+// MoveAfterContext 同 MoveAfter,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) MoveAfterContext(ctx context.Context, gid, target string) (position int, err error) {
+	return a.moveRelative(ctx, gid, target, 1)
+}
+
+// moveRelative 将任务移动到 target 的位置加上 shift
+// aria2 使用 POS_SET 时先从队列中移除任务再插入,target 在任务后面时位置需要减 1
+func (a Aria2Client) moveRelative(ctx context.Context, gid, target string, shift int) (position int, err error) {
+	if gid == target {
+		return 0, fmt.Errorf("cannot move %s relative to itself", gid)
+	}
+	gids, err := a.WaitingGidsContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	index, err := queueIndex(gids, gid, target)
+	if err != nil {
+		return 0, err
+	}
+
+	pos := index[target] + shift
+	if index[gid] < index[target] {
+		pos--
+	}
+	return a.ChangePositionContext(ctx, gid, pos, POS_SET)
+}
+
+// Swap 交换两个任务在等待队列中的位置
+func (a Aria2Client) Swap(gid1, gid2 string) error {
+	return a.SwapContext(context.Background(), gid1, gid2)
+}
+
+// SwapContext 同 Swap,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) SwapContext(ctx context.Context, gid1, gid2 string) error {
+	if gid1 == gid2 {
+		return nil
+	}
+	gids, err := a.WaitingGidsContext(ctx)
+	if err != nil {
+		return err
+	}
+	index, err := queueIndex(gids, gid1, gid2)
+	if err != nil {
+		return err
+	}
+
+	// 先把前面的任务移动到后面任务的位置,后面的任务会前移一位,再把它移动到前面任务原来的位置
+	front, back := gid1, gid2
+	if index[front] > index[back] {
+		front, back = back, front
+	}
+	return a.sendPositionChanges(ctx, []positionChange{
+		{gid: front, pos: index[back]},
+		{gid: back, pos: index[front]},
+	})
+}
+
+// Reorder 按 gids 的顺序重新排列等待队列
+// gids 中的任务依次排在队列最前面,不在 gids 中的任务保持原有的相对顺序排在后面
+// 所有位置修改通过一次 system.multicall 发送
+func (a Aria2Client) Reorder(gids []string) error {
+	return a.ReorderContext(context.Background(), gids)
+}
+
+// ReorderContext 同 Reorder,通过 ctx 控制请求的取消和超时
+func (a Aria2Client) ReorderContext(ctx context.Context, gids []string) error {
+	queue, err := a.WaitingGidsContext(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := queueIndex(queue, gids...); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(gids))
+	for _, gid := range gids {
+		if seen[gid] {
+			return fmt.Errorf("duplicate gid %s", gid)
+		}
+		seen[gid] = true
+	}
+
+	// 在本地模拟移动过程,跳过已经在正确位置的任务
+	changes := make([]positionChange, 0)
+	for pos, gid := range gids {
+		if queue[pos] == gid {
+			continue
+		}
+		current := pos
+		for queue[current] != gid {
+			current++
+		}
+		copy(queue[pos+1:current+1], queue[pos:current])
+		queue[pos] = gid
+		changes = append(changes, positionChange{gid: gid, pos: pos})
+	}
+	return a.sendPositionChanges(ctx, changes)
+}
+
+// positionChange 一次 POS_SET 位置修改
+type positionChange struct {
+	gid string
+	pos int
+}
+
+// sendPositionChanges 通过 system.multicall 按顺序发送位置修改
+func (a Aria2Client) sendPositionChanges(ctx context.Context, changes []positionChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	batch := a.NewMultiCallBatch()
+	results := make([]*MultiCallResult[int], 0, len(changes))
+	for _, change := range changes {
+		results = append(results, AddMultiCall[int](batch, NewRequestWithToken(a.Token).ChangePosition(change.gid, change.pos, POS_SET)))
+	}
+	if err := batch.SendContext(ctx); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+	return nil
+}
+
+// queueIndex 返回 gids 在等待队列中的位置,任务不在等待队列中时返回 ErrGIDNotFound
+func queueIndex(queue []string, gids ...string) (map[string]int, error) {
+	positions := make(map[string]int, len(queue))
+	for i, gid := range queue {
+		positions[gid] = i
+	}
+	index := make(map[string]int, len(gids))
+	for _, gid := range gids {
+		pos, ok := positions[gid]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not in the waiting queue", ErrGIDNotFound, gid)
+		}
+		index[gid] = pos
+	}
+	return index, nil
+}
@@ -0,0 +1,115 @@
+package aria2go
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// newQueueHandler 模拟 aria2 的等待队列,支持 tellWaiting changePosition system.multicall
+func newQueueHandler(queue *[]string) http.Handler {
+	call := func(method string, params []interface{}) interface{} {
+		switch method {
+		case "aria2.tellWaiting":
+			offset, num := int(params[1].(float64)), int(params[2].(float64))
+			tasks := make([]map[string]string, 0)
+			for i := offset; i < len(*queue) && i < offset+num; i++ {
+				tasks = append(tasks, map[string]string{"gid": (*queue)[i]})
+			}
+			return tasks
+		case "aria2.changePosition":
+			gid, pos := params[1].(string), int(params[2].(float64))
+			items := make([]string, 0, len(*queue))
+			for _, item := range *queue {
+				if item != gid {
+					items = append(items, item)
+				}
+			}
+			if params[3] == string(POS_END) || pos > len(items) {
+				pos = len(items)
+			}
+			items = append(items[:pos], append([]string{gid}, items[pos:]...)...)
+			*queue = items
+			return pos
+		}
+		return nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &RequestBody{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		var result interface{}
+		if request.Method == "system.multicall" {
+			results := make([]interface{}, 0)
+			for _, item := range request.Params[0].([]interface{}) {
+				method := item.(map[string]interface{})
+				results = append(results, []interface{}{call(method["methodName"].(string), method["params"].([]interface{}))})
+			}
+			result = results
+		} else {
+			result = call(request.Method, request.Params)
+		}
+		data, _ := json.Marshal(result)
+		_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","result":%s}`, request.ReplayID, data)
+	})
+}
+
+func TestQueueManager(t *testing.T) {
+	queue := []string{"a", "b", "c", "d", "e"}
+	queueClient := newTestClient(t, newQueueHandler(&queue))
+
+	check := func(name string, want ...string) {
+		t.Helper()
+		if !reflect.DeepEqual(queue, want) {
+			t.Errorf("%s: got %v want %v", name, queue, want)
+		}
+	}
+
+	if _, err := queueClient.MoveToFront("c"); err != nil {
+		t.Fatal(err)
+	}
+	check("MoveToFront", "c", "a", "b", "d", "e")
+
+	if _, err := queueClient.MoveToBack("c"); err != nil {
+		t.Fatal(err)
+	}
+	check("MoveToBack", "a", "b", "d", "e", "c")
+
+	if _, err := queueClient.MoveBefore("a", "e"); err != nil {
+		t.Fatal(err)
+	}
+	check("MoveBefore", "b", "d", "a", "e", "c")
+
+	if _, err := queueClient.MoveAfter("c", "b"); err != nil {
+		t.Fatal(err)
+	}
+	check("MoveAfter", "b", "c", "d", "a", "e")
+
+	if err := queueClient.Swap("e", "c"); err != nil {
+		t.Fatal(err)
+	}
+	check("Swap", "b", "e", "d", "a", "c")
+
+	if err := queueClient.Reorder([]string{"a", "b", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	check("Reorder", "a", "b", "c", "e", "d")
+
+	if _, err := queueClient.MoveBefore("a", "x"); !errors.Is(err, ErrGIDNotFound) {
+		t.Errorf("want gid not found got %v", err)
+	}
+	if err := queueClient.Reorder([]string{"a", "a"}); err == nil {
+		t.Error("want duplicate gid error")
+	}
+
+	request := NewRequestWithToken("thanks").AddUri([]string{"http://a/b"}, nil, 2)
+	if _, _, err := request.Create(); err != nil {
+		t.Fatal(err)
+	}
+	if len(request.Params) != 4 || request.Params[3] != 2 {
+		t.Errorf("unexpected AddUri params %v", request.Params)
+	}
+}
@@ -66,10 +66,31 @@ func (r *RequestBody) Create() (result []byte, replayID string, err error) {
 	return
 }
 
+// CreateBatch 创建 jsonrpc batch 请求数据
+// 每个请求分别生成 ReplayID,并且需要各自设置 token
+// replayIDs 与 requests 顺序一致
+func CreateBatch(requests ...*RequestBody) (result []byte, replayIDs []string, err error) {
+	if len(requests) < 1 {
+		return nil, nil, errors.New("number of request must be gt than eq to 1")
+	}
+
+	replayIDs = make([]string, 0, len(requests))
+	for _, item := range requests {
+		if item.errorInfo != nil {
+			return nil, nil, item.errorInfo
+		}
+		item.ReplayID = uuid.New().String()
+		replayIDs = append(replayIDs, item.ReplayID)
+	}
+	result, err = json.Marshal(requests)
+	return
+}
+
 // addParamsOption 添加 option 数据到 params 中
-func (r *RequestBody) addParamsOption(option *Option) {
+// 参数值不符合要求或者参数不能在 scope 中使用时设置 errorInfo
+func (r *RequestBody) addParamsOption(option *Option, scope OptionScope) {
 	if option != nil {
-		availableOption := make(map[string]string)
+		availableOption := make(map[string]interface{})
 
 		v := reflect.ValueOf(*option)
 		t := reflect.TypeOf(*option)
@@ -79,7 +100,15 @@ func (r *RequestBody) addParamsOption(option *Option) {
 			value := v.Field(i).Interface().(string)
 
 			if value != "" && key != "" {
-				availableOption[key] = value
+				if err := validateOption(key, value); err != nil {
+					r.errorInfo = err
+					return
+				}
+				if err := checkOptionScope(scope, key, value); err != nil {
+					r.errorInfo = err
+					return
+				}
+				availableOption[key] = optionParamValue(key, value)
 			}
 		}
 
@@ -87,8 +116,33 @@ func (r *RequestBody) addParamsOption(option *Option) {
 	}
 }
 
+// addParamsOptionPosition 添加 option 和 position 数据到 params 中
+// aria2 按位置解析参数,设置 position 时 option 为 nil 也需要传递空的 option
+func (r *RequestBody) addParamsOptionPosition(option *Option, scope OptionScope, position []int) {
+	if len(position) > 1 {
+		r.errorInfo = errors.New("position limit 1 value")
+		return
+	}
+	if len(position) == 1 && position[0] < 0 {
+		r.errorInfo = fmt.Errorf("position must be greater than or equal to 0, got %d", position[0])
+		return
+	}
+
+	if option == nil && len(position) == 1 {
+		option = &Option{}
+	}
+	r.addParamsOption(option, scope)
+	if r.errorInfo != nil {
+		return
+	}
+	if len(position) == 1 {
+		r.Params = append(r.Params, position[0])
+	}
+}
+
 // AddUri 下载文件请求
-func (r *RequestBody) AddUri(downloadSourceUri []string, option *Option) *RequestBody {
+// @position: 限制传递一个,新任务在等待队列中的位置,从 0 开始,不传递时添加到队列末尾
+func (r *RequestBody) AddUri(downloadSourceUri []string, option *Option, position ...int) *RequestBody {
 	if r.errorInfo != nil {
 		return r
 	}
@@ -100,7 +154,7 @@ func (r *RequestBody) AddUri(downloadSourceUri []string, option *Option) *Reques
 
 	r.Method = "aria2.addUri"
 	r.Params = append(r.Params, downloadSourceUri)
-	r.addParamsOption(option)
+	r.addParamsOptionPosition(option, OPTION_SCOPE_INPUT_FILE, position)
 
 	return r
 }
@@ -110,30 +164,115 @@ func (r *RequestBody) AddTorrent(torrentFilePath string, option *Option) *Reques
 	if r.errorInfo != nil {
 		return r
 	}
-	torrentFile, err := os.Open(torrentFilePath)
+	fileContent, err := readLocalFile(torrentFilePath)
 	if err != nil {
 		r.errorInfo = err
 		return r
 	}
-	fileContent, err := io.ReadAll(torrentFile)
+	return r.AddTorrentData(fileContent, nil, option)
+}
+
+// AddTorrentData 使用 bt 文件内容创建下载任务
+// @uris: web-seed 地址,单文件的种子为文件地址,多文件的种子为根目录地址,会自动拼接种子中的文件路径
+// @position: 限制传递一个,新任务在等待队列中的位置,从 0 开始,不传递时添加到队列末尾
+func (r *RequestBody) AddTorrentData(torrent []byte, uris []string, option *Option, position ...int) *RequestBody {
+	if r.errorInfo != nil {
+		return r
+	}
+	if len(torrent) < 1 {
+		r.errorInfo = errors.New("torrent content is required")
+		return r
+	}
+
+	r.Method = "aria2.addTorrent"
+	r.Params = append(r.Params, base64.StdEncoding.EncodeToString(torrent))
+	// aria2 按位置解析参数,设置 option 或 position 时 uris 不能省略
+	if len(uris) > 0 || option != nil || len(position) > 0 {
+		if uris == nil {
+			uris = []string{}
+		}
+		r.Params = append(r.Params, uris)
+	}
+	r.addParamsOptionPosition(option, OPTION_SCOPE_INPUT_FILE, position)
+
+	return r
+}
+
+// AddTorrentReader 从 reader 读取 bt 文件内容创建下载任务,参数同 AddTorrentData
+func (r *RequestBody) AddTorrentReader(reader io.Reader, uris []string, option *Option, position ...int) *RequestBody {
+	if r.errorInfo != nil {
+		return r
+	}
+	torrent, err := io.ReadAll(reader)
 	if err != nil {
 		r.errorInfo = err
 		return r
 	}
-	err = torrentFile.Close()
+	return r.AddTorrentData(torrent, uris, option, position...)
+}
+
+// AddMetalink 添加本地 metalink 文件创建下载任务
+// 一个 metalink 文件可能会创建多个任务,返回结果为 gid 列表
+// @position: 限制传递一个,新任务在等待队列中的位置,从 0 开始,不传递时添加到队列末尾
+func (r *RequestBody) AddMetalink(metalinkFilePath string, option *Option, position ...int) *RequestBody {
+	if r.errorInfo != nil {
+		return r
+	}
+	fileContent, err := readLocalFile(metalinkFilePath)
 	if err != nil {
 		r.errorInfo = err
 		return r
 	}
-	torrent := base64.StdEncoding.EncodeToString(fileContent)
+	return r.AddMetalinkData(fileContent, option, position...)
+}
 
-	r.Method = "aria2.addTorrent"
-	r.Params = append(r.Params, torrent)
-	r.addParamsOption(option)
+// AddMetalinkData 使用 metalink 文件内容创建下载任务
+func (r *RequestBody) AddMetalinkData(metalink []byte, option *Option, position ...int) *RequestBody {
+	if r.errorInfo != nil {
+		return r
+	}
+	if len(metalink) < 1 {
+		r.errorInfo = errors.New("metalink content is required")
+		return r
+	}
+
+	r.Method = "aria2.addMetalink"
+	r.Params = append(r.Params, base64.StdEncoding.EncodeToString(metalink))
+	r.addParamsOptionPosition(option, OPTION_SCOPE_INPUT_FILE, position)
 
 	return r
 }
 
+// AddMetalinkReader 从 reader 读取 metalink 文件内容创建下载任务
+func (r *RequestBody) AddMetalinkReader(reader io.Reader, option *Option, position ...int) *RequestBody {
+	if r.errorInfo != nil {
+		return r
+	}
+	metalink, err := io.ReadAll(reader)
+	if err != nil {
+		r.errorInfo = err
+		return r
+	}
+	return r.AddMetalinkData(metalink, option, position...)
+}
+
+// readLocalFile 读取本地文件内容
+func readLocalFile(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
 // Remove 删除下载记录
 // 如果 force 为 true 则会直接删除.不会执行其他操作,例如联系 BitTorrent trackers 取消下载
 func (r *RequestBody) Remove(gid string, force bool) *RequestBody {
@@ -198,22 +337,32 @@ func (r *RequestBody) UnpauseAll() *RequestBody {
 }
 
 // TellStatus 查询任务状态
-// keys 可以指定返回字段,可指定的字段名参考 TaskStatusData
+// keys 可以指定返回字段,可指定的字段名参考 TaskStatusData,也可以使用 TaskField.Keys 生成
 func (r *RequestBody) TellStatus(gid string, keys ...string) *RequestBody {
 	if r.errorInfo != nil {
 		return r
 	}
 
-	// TODO: 等待实现
 	r.Method = "aria2.tellStatus"
 	r.Params = append(r.Params, gid)
-	if len(keys) > 0 {
-		r.Params = append(r.Params, keys)
-	}
+	r.addParamsKeys(keys)
 
 	return r
 }
 
+// addParamsKeys 添加 keys 数据到 params 中,keys 不是 TaskStatusData 中的字段时设置 errorInfo
+// 可以使用 TaskField.Keys 生成 keys
+func (r *RequestBody) addParamsKeys(keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	if err := ValidateTaskKeys(keys...); err != nil {
+		r.errorInfo = err
+		return
+	}
+	r.Params = append(r.Params, keys)
+}
+
 // TellActive 查询所有正在进行中的任务
 // keys 可以指定返回字段,可指定的字段名参考 TaskStatusData
 func (r *RequestBody) TellActive(keys ...string) *RequestBody {
@@ -221,32 +370,32 @@ func (r *RequestBody) TellActive(keys ...string) *RequestBody {
 		return r
 	}
 	r.Method = "aria2.tellActive"
-	if len(keys) > 0 {
-		r.Params = append(r.Params, keys)
-	}
+	r.addParamsKeys(keys)
 	return r
 }
 
 // TellWaiting 查询等待执行的任务
 // offset 设置偏移量 limit 限制每次显示多少
 func (r *RequestBody) TellWaiting(offset, limit int, keys ...string) *RequestBody {
+	if r.errorInfo != nil {
+		return r
+	}
 	r.Method = "aria2.tellWaiting"
 	r.Params = append(r.Params, offset)
 	r.Params = append(r.Params, limit)
-	if len(keys) > 0 {
-		r.Params = append(r.Params, keys)
-	}
+	r.addParamsKeys(keys)
 	return r
 }
 
 // TellStopped 查询已经完成或者停止的任务
 func (r *RequestBody) TellStopped(offset, limit int, keys ...string) *RequestBody {
+	if r.errorInfo != nil {
+		return r
+	}
 	r.Method = "aria2.tellStopped"
 	r.Params = append(r.Params, offset)
 	r.Params = append(r.Params, limit)
-	if len(keys) > 0 {
-		r.Params = append(r.Params, keys)
-	}
+	r.addParamsKeys(keys)
 	return r
 }
 
@@ -336,7 +485,7 @@ func (r *RequestBody) GetOption(gid string) *RequestBody {
 //	piece-length
 //	rpc-save-upload-metadata
 //
-// 除了下面的参数外,更改其他参数会使任务重新启动
+// 除了下面的参数外,更改其他参数会使任务重新启动,可以通过 OptionRequiresRestart Option.RestartKeys 判断
 // 重启由 aria2 自行执行,不需要用户主动操作
 //
 //	bt-max-peers
@@ -345,13 +494,22 @@ func (r *RequestBody) GetOption(gid string) *RequestBody {
 //	force-save
 //	max-download-limit
 //	max-upload-limit
-func (r *RequestBody) ChangeOption(gid string, opts *Option) *RequestBody {
+//
+// @otherOpt: 限制传递一个 map[string]string 用于设置 Option 中没有的参数
+func (r *RequestBody) ChangeOption(gid string, opts *Option, otherOpt ...map[string]string) *RequestBody {
 	if r.errorInfo != nil {
 		return r
 	}
+	if len(otherOpt) > 1 {
+		r.errorInfo = errors.New("otherOpt limit 1 map data")
+		return r
+	}
 	r.Method = "aria2.changeOption"
 	r.Params = append(r.Params, gid)
-	r.addParamsOption(opts)
+	r.addParamsOption(opts, OPTION_SCOPE_CHANGE)
+	if len(otherOpt) == 1 {
+		r.addParamsOtherOption(opts, OPTION_SCOPE_CHANGE, otherOpt[0])
+	}
 	return r
 }
 
@@ -395,30 +553,47 @@ func (r *RequestBody) ChangeGlobalOption(opts *Option, otherOpt ...map[string]st
 	if r.errorInfo != nil {
 		return r
 	}
+	if len(otherOpt) > 1 {
+		r.errorInfo = errors.New("otherOpt limit 1 map data")
+		return r
+	}
 	r.Method = "aria2.changeGlobalOption"
-	r.addParamsOption(opts)
+	r.addParamsOption(opts, OPTION_SCOPE_GLOBAL)
+	if len(otherOpt) == 1 {
+		r.addParamsOtherOption(opts, OPTION_SCOPE_GLOBAL, otherOpt[0])
+	}
+	return r
+}
 
-	if opts == nil && len(otherOpt) != 0 {
-		r.Params = append(r.Params, make(map[string]string))
+// addParamsOtherOption 将 Option 中没有的参数合并到 addParamsOption 添加的参数中
+// opts 为 nil 时 addParamsOption 没有添加参数,需要先添加
+func (r *RequestBody) addParamsOtherOption(opts *Option, scope OptionScope, otherOpt map[string]string) {
+	if r.errorInfo != nil {
+		return
+	}
+	if opts == nil {
+		r.Params = append(r.Params, make(map[string]interface{}))
 	}
 
-	optsMap, ok := r.Params[1].(map[string]string)
+	optsMap, ok := r.Params[len(r.Params)-1].(map[string]interface{})
 	if !ok {
 		r.errorInfo = errors.New("assert params type error")
-		return r
+		return
 	}
 
-	if len(otherOpt) == 1 {
-		for key, val := range otherOpt[0] {
-			if strings.TrimSpace(val) != "" {
-				optsMap[key] = val
+	for key, val := range otherOpt {
+		if strings.TrimSpace(val) != "" {
+			if err := validateOption(key, val); err != nil {
+				r.errorInfo = err
+				return
+			}
+			if err := checkOptionScope(scope, key, val); err != nil {
+				r.errorInfo = err
+				return
 			}
+			optsMap[key] = optionParamValue(key, val)
 		}
-	} else {
-		r.errorInfo = errors.New("otherOpt limit 1 map data")
-		return r
 	}
-	return r
 }
 
 func (r *RequestBody) GetGlobalStat() *RequestBody {
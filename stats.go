@@ -0,0 +1,123 @@
+package aria2go
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TaskStats TaskStatusData 中数值字段的类型化数据
+// aria2 返回的数值都是字符串,通过 TaskStatusData.Stats 转换
+// 使用 keys 只查询部分字段时,未返回的字段为 0
+type TaskStats struct {
+	Gid             string
+	Status          TaskStatus
+	TotalLength     int64
+	CompletedLength int64
+	UploadLength    int64
+	DownloadSpeed   int64
+	UploadSpeed     int64
+	Connections     int64
+	NumPieces       int64
+	PieceLength     int64
+	NumSeeders      int64
+	Seeder          bool
+}
+
+// Progress 下载进度,范围 0-1,总大小未知时返回 0
+func (t *TaskStats) Progress() float64 {
+	if t.TotalLength <= 0 {
+		return 0
+	}
+	return float64(t.CompletedLength) / float64(t.TotalLength)
+}
+
+// ETA 按当前下载速度计算的剩余时间
+// 下载速度为 0 或总大小未知时 ok 为 false
+func (t *TaskStats) ETA() (eta time.Duration, ok bool) {
+	if t.DownloadSpeed <= 0 || t.TotalLength <= 0 {
+		return 0, false
+	}
+	remaining := t.TotalLength - t.CompletedLength
+	if remaining <= 0 {
+		return 0, true
+	}
+	return time.Duration(float64(remaining) / float64(t.DownloadSpeed) * float64(time.Second)), true
+}
+
+// ShareRatio 分享率,上传量 / 已下载量,未下载时返回 0
+func (t *TaskStats) ShareRatio() float64 {
+	if t.CompletedLength <= 0 {
+		return 0
+	}
+	return float64(t.UploadLength) / float64(t.CompletedLength)
+}
+
+// Stats 将数值字段转换为 TaskStats
+func (t *TaskStatusData) Stats() (*TaskStats, error) {
+	stats := &TaskStats{
+		Gid:    t.Gid,
+		Status: TaskStatus(t.Status),
+		Seeder: t.Seeder == "true",
+	}
+
+	parser := &numberParser{}
+	stats.TotalLength = parser.parse("totalLength", t.TotalLength)
+	stats.CompletedLength = parser.parse("completedLength", t.CompletedLength)
+	stats.UploadLength = parser.parse("uploadLength", t.UploadLength)
+	stats.DownloadSpeed = parser.parse("downloadSpeed", t.DownloadSpeed)
+	stats.UploadSpeed = parser.parse("uploadSpeed", t.UploadSpeed)
+	stats.Connections = parser.parse("connections", t.Connections)
+	stats.NumPieces = parser.parse("numPieces", t.NumPieces)
+	stats.PieceLength = parser.parse("pieceLength", t.PieceLength)
+	stats.NumSeeders = parser.parse("numSeeders", t.NumSeeders)
+	if parser.err != nil {
+		return nil, parser.err
+	}
+	return stats, nil
+}
+
+// GlobalStats GlobalStatData 中数值字段的类型化数据
+type GlobalStats struct {
+	DownloadSpeed   int64
+	UploadSpeed     int64
+	NumActive       int64
+	NumWaiting      int64
+	NumStopped      int64
+	NumStoppedTotal int64
+}
+
+// Stats 将数值字段转换为 GlobalStats
+func (g *GlobalStatData) Stats() (*GlobalStats, error) {
+	stats := &GlobalStats{}
+
+	parser := &numberParser{}
+	stats.DownloadSpeed = parser.parse("downloadSpeed", g.DownloadSpeed)
+	stats.UploadSpeed = parser.parse("uploadSpeed", g.UploadSpeed)
+	stats.NumActive = parser.parse("numActive", g.NumActive)
+	stats.NumWaiting = parser.parse("numWaiting", g.NumWaiting)
+	stats.NumStopped = parser.parse("numStopped", g.NumStopped)
+	stats.NumStoppedTotal = parser.parse("numStoppedTotal", g.NumStoppedTotal)
+	if parser.err != nil {
+		return nil, parser.err
+	}
+	return stats, nil
+}
+
+// numberParser 解析 aria2 返回的数值字符串,记录第一个错误
+type numberParser struct {
+	err error
+}
+
+// parse 解析数值,空字符串返回 0
+func (p *numberParser) parse(name, value string) int64 {
+	if p.err != nil || value == "" {
+		return 0
+	}
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		p.err = &DecodeError{Err: fmt.Errorf("parse %s: %w", name, err)}
+		return 0
+	}
+	return number
+}
@@ -0,0 +1,37 @@
+package aria2go
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTaskStats(t *testing.T) {
+	task := &TaskStatusData{
+		Gid:             "2089b05ecca3d829",
+		Status:          "active",
+		TotalLength:     "1000",
+		CompletedLength: "250",
+		UploadLength:    "500",
+		DownloadSpeed:   "75",
+		Connections:     "4",
+	}
+	stats, err := task.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Status != STATUS_ACTIVE || stats.Connections != 4 {
+		t.Errorf("unexpected stats %#v", stats)
+	}
+	if stats.Progress() != 0.25 || stats.ShareRatio() != 2 {
+		t.Errorf("progress %f share ratio %f", stats.Progress(), stats.ShareRatio())
+	}
+	if eta, ok := stats.ETA(); !ok || eta != 10*time.Second {
+		t.Errorf("eta %v %v", eta, ok)
+	}
+
+	task.DownloadSpeed = "fast"
+	if _, err := task.Stats(); !errors.Is(err, ErrDecode) {
+		t.Errorf("want decode error got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package aria2go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Transport 与 aria2 通信的传输层
+// 可以通过 ClientSetTransport 替换为自定义实现
+type Transport interface {
+	// SendRequest 发送请求体,返回 aria2 响应的原始数据
+	SendRequest(ctx context.Context, body []byte) ([]byte, error)
+	// Close 释放传输层持有的连接
+	Close() error
+}
+
+// httpTransport 通过 http POST 发送请求
+type httpTransport struct {
+	url    string
+	client *http.Client
+	header http.Header
+
+	// owned client 由本库创建时为 true,只有这种情况 Close 才会关闭空闲连接
+	owned bool
+}
+
+// NewHTTPTransport 创建 http 传输层
+// client 为 nil 时使用 http.DefaultClient,header 会附加到每个请求中
+// client 由调用方管理,Close 不会关闭 client 的连接
+func NewHTTPTransport(url string, client *http.Client, header http.Header) Transport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpTransport{url: url, client: client, header: header.Clone()}
+}
+
+func (h *httpTransport) SendRequest(ctx context.Context, body []byte) (result []byte, err error) {
+	request, err := http.NewRequestWithContext(ctx, "POST", h.url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range h.header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	request.Header.Set("Content-Type", DEFAULT_CONTENT_TYPE)
+	request.Header.Set("Accept-Charset", "utf-8")
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	resultJsonData, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// aria2 返回 jsonrpc 错误时 http 状态码也不是 2xx,这种情况交给调用方解析错误信息
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		if !isRPCErrorBody(resultJsonData) {
+			return nil, &TransportError{
+				StatusCode: response.StatusCode,
+				Err:        fmt.Errorf("unexpected http status %s", response.Status),
+			}
+		}
+	}
+
+	result = resultJsonData
+	return
+}
+
+// isRPCErrorBody 判断数据是否为包含错误信息的 jsonrpc 响应,batch 响应中任意元素包含错误即可
+func isRPCErrorBody(data []byte) bool {
+	resp := make([]*BasicModel, 0)
+	if err := json.Unmarshal(data, &resp); err != nil {
+		item := &BasicModel{}
+		if err := json.Unmarshal(data, item); err != nil {
+			return false
+		}
+		resp = append(resp, item)
+	}
+
+	for _, item := range resp {
+		if item != nil && item.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Close 关闭本库创建的 client 的空闲连接,共享的或调用方传入的 client 不做处理
+func (h *httpTransport) Close() error {
+	if h.owned {
+		h.client.CloseIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package aria2go
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+var (
+	// waitPollInterval 轮询任务状态的初始间隔
+	waitPollInterval = 500 * time.Millisecond
+	// waitMaxPollInterval 轮询任务状态的最大间隔,使用通知时以该间隔轮询作为兜底
+	waitMaxPollInterval = 5 * time.Second
+)
+
+// waitFields 等待过程中轮询需要的字段
+const waitFields = FIELD_GID | FIELD_STATUS | FIELD_SEEDER | FIELD_ERROR_CODE | FIELD_ERROR_MESSAGE | FIELD_FOLLOWED_BY
+
+// Wait 阻塞等待任务下载完成,返回最终任务的完整状态
+// 使用 websocket 时通过通知获知任务状态变化,否则按逐渐增加的间隔轮询
+// magnet 或 .torrent 链接的任务完成后会沿 followedBy 继续等待生成的任务,生成多个任务时依次等待,返回最后一个任务的状态
+// BitTorrent 任务下载完成开始做种时视为完成
+// 任务失败或者被删除时返回 *DownloadError,可以通过 errors.Is(err, ErrDownloadFailed) 判断
+func (a Aria2Client) Wait(gid string) (status *TaskStatusData, err error) {
+	return a.WaitContext(context.Background(), gid)
+}
+
+// WaitContext 同 Wait,通过 ctx 控制等待的取消和超时
+func (a Aria2Client) WaitContext(ctx context.Context, gid string) (status *TaskStatusData, err error) {
+	var events <-chan *NotificationEvent
+	if a.ws != nil {
+		notifications, cancel, err := a.Notifications(ON_DOWNLOAD_COMPLETE, ON_BT_DOWNLOAD_COMPLETE, ON_DOWNLOAD_ERROR, ON_DOWNLOAD_STOP)
+		if err == nil {
+			defer cancel()
+			events = notifications
+		}
+	}
+
+	visited := make(map[string]bool)
+	queue := []string{gid}
+	var last string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		task, err := a.waitTask(ctx, current, events)
+		if err != nil {
+			return nil, err
+		}
+		if len(task.FollowedBy) > 0 {
+			queue = append(queue, task.FollowedBy...)
+			continue
+		}
+		last = current
+	}
+	if last == "" {
+		last = gid
+	}
+
+	status, _, err = CallContext[*TaskStatusData](ctx, &a, NewRequestWithToken(a.Token).TellStatus(last))
+	return status, err
+}
+
+// waitTask 等待单个任务结束,只查询 waitFields 中的字段
+func (a Aria2Client) waitTask(ctx context.Context, gid string, events <-chan *NotificationEvent) (*TaskStatusData, error) {
+	interval := waitPollInterval
+	if events != nil {
+		interval = waitMaxPollInterval
+	}
+
+	for {
+		status, _, err := CallContext[*TaskStatusData](ctx, &a, NewRequestWithToken(a.Token).TellStatus(gid, waitFields.Keys()...))
+		if err != nil {
+			return nil, err
+		}
+		switch TaskStatus(status.Status) {
+		case STATUS_COMPLETE:
+			return status, nil
+		case STATUS_ACTIVE:
+			if status.Seeder == "true" {
+				return status, nil
+			}
+		case STATUS_ERROR, STATUS_REMOVED:
+			code, _ := strconv.Atoi(status.ErrorCode)
+			return nil, &DownloadError{
+				Gid:          gid,
+				Status:       TaskStatus(status.Status),
+				ErrorCode:    code,
+				ErrorMessage: status.ErrorMessage,
+			}
+		}
+
+		if err := waitEvent(ctx, gid, events, interval); err != nil {
+			return nil, err
+		}
+		if interval *= 2; interval > waitMaxPollInterval {
+			interval = waitMaxPollInterval
+		}
+	}
+}
+
+// waitEvent 等待 gid 的通知或者轮询间隔到期
+func waitEvent(ctx context.Context, gid string, events <-chan *NotificationEvent, interval time.Duration) error {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if event.Gid == gid {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package aria2go
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWait(t *testing.T) {
+	waitPollInterval = time.Millisecond
+	waitMaxPollInterval = 5 * time.Millisecond
+	defer func() {
+		waitPollInterval = 500 * time.Millisecond
+		waitMaxPollInterval = 5 * time.Second
+	}()
+
+	// metadata 任务轮询两次后完成并生成 BitTorrent 任务,failed 任务下载失败
+	polls := make(map[string]int)
+	waitClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := &RequestBody{}
+		_ = json.NewDecoder(r.Body).Decode(request)
+		gid := request.Params[1].(string)
+		polls[gid]++
+
+		result := `{}`
+		switch {
+		case gid == "metadata" && polls[gid] < 3:
+			result = `{"gid":"metadata","status":"active"}`
+		case gid == "metadata":
+			result = `{"gid":"metadata","status":"complete","followedBy":["torrent"]}`
+		case gid == "torrent" && len(request.Params) == 2:
+			result = `{"gid":"torrent","status":"active","seeder":"true","dir":"/data"}`
+		case gid == "torrent":
+			result = `{"gid":"torrent","status":"active","seeder":"true"}`
+		case gid == "failed":
+			result = `{"gid":"failed","status":"error","errorCode":"3","errorMessage":"Resource not found"}`
+		case gid == "paused":
+			result = `{"gid":"paused","status":"paused"}`
+		}
+		_, _ = fmt.Fprintf(w, `{"id":"%s","jsonrpc":"2.0","result":%s}`, request.ReplayID, result)
+	}))
+
+	status, err := waitClient.Wait("metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Gid != "torrent" || status.Dir != "/data" {
+		t.Errorf("unexpected status %#v", status)
+	}
+
+	_, err = waitClient.Wait("failed")
+	downloadErr := &DownloadError{}
+	if !errors.Is(err, ErrDownloadFailed) || !errors.As(err, &downloadErr) || downloadErr.ErrorCode != 3 {
+		t.Errorf("want download error got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := waitClient.WaitContext(ctx, "paused"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline exceeded got %v", err)
+	}
+}
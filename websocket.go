@@ -0,0 +1,382 @@
+package aria2go
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// websocketReconnectInterval 连接断开后第一次重新连接前的等待时间
+	websocketReconnectInterval = 500 * time.Millisecond
+	// websocketMaxReconnectInterval 重新连接的最大等待时间
+	websocketMaxReconnectInterval = 30 * time.Second
+)
+
+// websocketConn aria2 websocket 连接
+// 所有请求复用同一个连接,通过请求中的 id(ReplayID) 匹配响应
+type websocketConn struct {
+	url    string
+	dialer *websocket.Dialer
+	header http.Header
+
+	// mu 保护 conn pending subscribers 以及下面的连接状态
+	mu               sync.Mutex
+	conn             *websocket.Conn
+	pending          map[string]chan websocketResult
+	subscribers      map[int]*notificationSubscriber
+	nextSubscriberID int
+	// readDone 当前连接的读取协程结束时关闭
+	readDone chan struct{}
+	// closeErr 最近一次关闭连接的原因
+	closeErr error
+	// lost 连接断开时有订阅者,重新连接后需要发送 ON_CONNECTION_RESTORED
+	lost bool
+	// reconnecting 后台重新连接的协程正在运行
+	reconnecting bool
+
+	// closed 调用 Close 后关闭,之后不再后台重新连接
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	// writeMu 保证同一时间只有一个写操作
+	writeMu sync.Mutex
+}
+
+type websocketResult struct {
+	data []byte
+	err  error
+}
+
+// websocketMessage 用于区分响应和通知
+type websocketMessage struct {
+	ID     *string `json:"id"`
+	Method string  `json:"method"`
+}
+
+func newWebsocketConn(url string, tlsConfig *tls.Config, header http.Header) *websocketConn {
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = tlsConfig
+
+	return &websocketConn{
+		url:         url,
+		dialer:      &dialer,
+		header:      header.Clone(),
+		pending:     make(map[string]chan websocketResult),
+		subscribers: make(map[int]*notificationSubscriber),
+		closed:      make(chan struct{}),
+	}
+}
+
+// connect 获取当前连接,连接不存在时重新建立连接
+func (w *websocketConn) connect(ctx context.Context) (*websocket.Conn, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil {
+		return w.conn, nil
+	}
+
+	conn, _, err := w.dialer.DialContext(ctx, w.url, w.header)
+	if err != nil {
+		return nil, err
+	}
+	prev := w.readDone
+	w.conn = conn
+	w.readDone = make(chan struct{})
+	go w.readLoop(conn, prev, w.readDone)
+
+	return conn, nil
+}
+
+// readLoop 持续读取连接中的消息并分发给等待中的请求
+// 先等待上一个连接的读取协程结束,保证订阅者按顺序收到事件
+func (w *websocketConn) readLoop(conn *websocket.Conn, prev <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	if prev != nil {
+		<-prev
+	}
+
+	w.mu.Lock()
+	restored := w.lost && w.conn == conn
+	if restored {
+		w.lost = false
+	}
+	w.mu.Unlock()
+	if restored {
+		w.dispatch(&NotificationEvent{Type: ON_CONNECTION_RESTORED})
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			w.closeWithError(conn, err)
+			w.connectionLost()
+			return
+		}
+
+		ids, err := messageIDs(data)
+		if err != nil {
+			continue
+		}
+		if len(ids) == 0 {
+			// 没有 id 的消息为 aria2 通知
+			w.notify(data)
+			continue
+		}
+
+		w.mu.Lock()
+		var ch chan websocketResult
+		for _, id := range ids {
+			if item, ok := w.pending[id]; ok {
+				ch = item
+				delete(w.pending, id)
+			}
+		}
+		w.mu.Unlock()
+
+		if ch != nil {
+			ch <- websocketResult{data: data}
+		}
+	}
+}
+
+// messageIDs 获取消息中的 id,batch 消息返回所有元素的 id
+func messageIDs(data []byte) ([]string, error) {
+	data = bytes.TrimSpace(data)
+	messages := make([]*websocketMessage, 0)
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, err
+		}
+	} else {
+		msg := &websocketMessage{}
+		if err := json.Unmarshal(data, msg); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	ids := make([]string, 0, len(messages))
+	for _, item := range messages {
+		if item.ID != nil {
+			ids = append(ids, *item.ID)
+		}
+	}
+	return ids, nil
+}
+
+// notify 将通知分发给所有订阅者
+func (w *websocketConn) notify(data []byte) {
+	events, err := decodeNotification(data)
+	if err != nil {
+		return
+	}
+	w.dispatch(events...)
+}
+
+// dispatch 将事件分发给接收该类型的订阅者
+func (w *websocketConn) dispatch(events ...*NotificationEvent) {
+	w.mu.Lock()
+	subscribers := make([]*notificationSubscriber, 0, len(w.subscribers))
+	for _, item := range w.subscribers {
+		subscribers = append(subscribers, item)
+	}
+	w.mu.Unlock()
+
+	for _, event := range events {
+		for _, item := range subscribers {
+			if item.accept(event.Type) {
+				item.handler(event)
+			}
+		}
+	}
+}
+
+// connectionLost 连接断开时通知订阅者,并在后台重新连接
+func (w *websocketConn) connectionLost() {
+	w.mu.Lock()
+	if len(w.subscribers) == 0 {
+		w.mu.Unlock()
+		return
+	}
+	w.lost = true
+	err := w.closeErr
+	start := !w.reconnecting
+	w.reconnecting = true
+	w.mu.Unlock()
+
+	w.dispatch(&NotificationEvent{Type: ON_CONNECTION_LOST, Err: err})
+	if start {
+		go w.reconnect()
+	}
+}
+
+// reconnect 按逐渐增加的间隔重新连接,连接成功、没有订阅者或者调用 Close 后结束
+func (w *websocketConn) reconnect() {
+	interval := websocketReconnectInterval
+	for {
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-w.closed:
+			timer.Stop()
+		}
+		if !w.needReconnect() {
+			return
+		}
+
+		if _, err := w.connect(context.Background()); err != nil {
+			if interval *= 2; interval > websocketMaxReconnectInterval {
+				interval = websocketMaxReconnectInterval
+			}
+			continue
+		}
+		// 连接成功后可能立即再次断开,此时重新开始计算间隔
+		if !w.needReconnect() {
+			return
+		}
+		interval = websocketReconnectInterval
+	}
+}
+
+// needReconnect 是否需要继续重新连接,不需要时结束 reconnecting 状态
+func (w *websocketConn) needReconnect() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	select {
+	case <-w.closed:
+		w.reconnecting = false
+	default:
+		w.reconnecting = w.conn == nil && len(w.subscribers) > 0
+	}
+	return w.reconnecting
+}
+
+// subscribe 添加通知订阅者
+func (w *websocketConn) subscribe(subscriber *notificationSubscriber) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nextSubscriberID++
+	w.subscribers[w.nextSubscriberID] = subscriber
+	return w.nextSubscriberID
+}
+
+// unsubscribe 移除通知订阅者
+func (w *websocketConn) unsubscribe(id int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.subscribers, id)
+}
+
+// closeWithError 关闭连接,并通知所有等待中的请求
+// batch 请求的多个 id 共用一个 channel,每个 channel 只通知一次,且在释放 mu 之后发送
+func (w *websocketConn) closeWithError(conn *websocket.Conn, err error) {
+	w.mu.Lock()
+	if w.conn != conn {
+		w.mu.Unlock()
+		return
+	}
+	_ = conn.Close()
+	w.conn = nil
+	w.closeErr = err
+
+	channels := make(map[chan websocketResult]bool, len(w.pending))
+	for id, ch := range w.pending {
+		channels[ch] = true
+		delete(w.pending, id)
+	}
+	w.mu.Unlock()
+
+	for ch := range channels {
+		select {
+		case ch <- websocketResult{err: err}:
+		default:
+		}
+	}
+}
+
+// SendRequest 发送请求并等待对应 id 的响应,batch 请求等待包含其中任意 id 的响应
+// ctx 结束时放弃等待,之后到达的响应会被丢弃
+func (w *websocketConn) SendRequest(ctx context.Context, body []byte) (result []byte, err error) {
+	ids, err := messageIDs(body)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("websocket request id is required")
+	}
+
+	conn, err := w.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan websocketResult, 1)
+	w.mu.Lock()
+	for _, id := range ids {
+		if _, ok := w.pending[id]; ok {
+			w.mu.Unlock()
+			return nil, fmt.Errorf("websocket request id %s is duplicated", id)
+		}
+	}
+	for _, id := range ids {
+		w.pending[id] = ch
+	}
+	w.mu.Unlock()
+
+	w.writeMu.Lock()
+	deadline, _ := ctx.Deadline()
+	err = conn.SetWriteDeadline(deadline)
+	if err == nil {
+		err = conn.WriteMessage(websocket.TextMessage, body)
+	}
+	w.writeMu.Unlock()
+	if err != nil {
+		w.removePending(ids)
+		w.closeWithError(conn, err)
+		return nil, err
+	}
+
+	select {
+	case res := <-ch:
+		return res.data, res.err
+	case <-ctx.Done():
+		w.removePending(ids)
+		return nil, ctx.Err()
+	}
+}
+
+// removePending 放弃等待指定 id 的响应
+func (w *websocketConn) removePending(ids []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, id := range ids {
+		delete(w.pending, id)
+	}
+}
+
+// Close 关闭连接,之后不再后台重新连接
+func (w *websocketConn) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.closed)
+	})
+
+	w.mu.Lock()
+	conn := w.conn
+	w.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	w.closeWithError(conn, errors.New("websocket connection closed"))
+	return nil
+}
@@ -0,0 +1,232 @@
+package aria2go
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestWebsocketMultiplex(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	wsClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// 收集一批请求后倒序响应,模拟响应乱序
+		requests := make([]*RequestBody, 0)
+		for len(requests) < 10 {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			req := &RequestBody{}
+			if err := json.Unmarshal(data, req); err != nil {
+				return
+			}
+			requests = append(requests, req)
+		}
+		for i := len(requests) - 1; i >= 0; i-- {
+			resp := &Response{Result: requests[i].Params[1].(string)}
+			resp.ID = requests[i].ReplayID
+			resp.JSONRPC = DEFAULT_JSONRPC_VERSION
+			if err := conn.WriteJSON(resp); err != nil {
+				return
+			}
+		}
+	}), ClientUseWebsocket())
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(gid string) {
+			defer wg.Done()
+			request, _, err := NewRequestWithToken(wsClient.Token).TellStatus(gid).Create()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			result, err := wsClient.SendRequest(request)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp := &Response{}
+			if err := json.Unmarshal(result, resp); err != nil {
+				t.Error(err)
+				return
+			}
+			if resp.Result != gid {
+				t.Errorf("want %s got %s", gid, resp.Result)
+			}
+		}(fmt.Sprintf("%016d", i))
+	}
+	wg.Wait()
+}
+
+func TestNotifications(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	wsClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for _, method := range []NotificationType{ON_DOWNLOAD_START, ON_DOWNLOAD_PAUSE, ON_DOWNLOAD_COMPLETE} {
+			notification := fmt.Sprintf(`{"jsonrpc":"2.0","method":"%s","params":[{"gid":"2089b05ecca3d829"}]}`, method)
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(notification)); err != nil {
+				return
+			}
+		}
+		_, _, _ = conn.ReadMessage()
+	}), ClientUseWebsocket())
+
+	events, cancel, err := wsClient.Notifications(ON_DOWNLOAD_START, ON_DOWNLOAD_COMPLETE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	for _, want := range []NotificationType{ON_DOWNLOAD_START, ON_DOWNLOAD_COMPLETE} {
+		event := <-events
+		if event.Type != want || event.Gid != "2089b05ecca3d829" {
+			t.Errorf("want %s got %s %s", want, event.Type, event.Gid)
+		}
+	}
+}
+
+func TestWebsocketBatchConnectionLost(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	connections := 0
+	mu := sync.Mutex{}
+	wsClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		mu.Lock()
+		connections++
+		first := connections == 1
+		mu.Unlock()
+
+		// 第一个连接读到 batch 请求后直接断开
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil || first {
+				return
+			}
+			req := &RequestBody{}
+			if err := json.Unmarshal(data, req); err != nil {
+				return
+			}
+			resp := fmt.Sprintf(`{"id":"%s","jsonrpc":"2.0","result":{"sessionId":"cd6a3bc6a1de28eb5bfa181e5f6b916d44af31a9"}}`, req.ReplayID)
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(resp)); err != nil {
+				return
+			}
+		}
+	}), ClientUseWebsocket(), ClientSetTimeout(time.Second))
+
+	// 连接断开时 batch 的所有 id 共用一个 channel,之后的请求需要能正常返回
+	done := make(chan error, 1)
+	go func() {
+		batch := wsClient.NewRequestBatch()
+		for i := 0; i < 3; i++ {
+			AddBatchCall[string](batch, NewRequestWithToken(wsClient.Token).Pause(fmt.Sprintf("%016d", i), false))
+		}
+		if err := batch.Send(); err == nil {
+			done <- errors.New("want connection error")
+			return
+		}
+		_, err := wsClient.GetSessionInfo()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("client hangs after connection lost during batch")
+	}
+}
+
+func TestNotificationsConnectionLost(t *testing.T) {
+	interval := websocketReconnectInterval
+	websocketReconnectInterval = 10 * time.Millisecond
+	defer func() { websocketReconnectInterval = interval }()
+
+	upgrader := websocket.Upgrader{}
+	connections := 0
+	mu := sync.Mutex{}
+	wsClient := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		mu.Lock()
+		connections++
+		first := connections == 1
+		mu.Unlock()
+
+		// 第一个连接发送一个通知后由服务端断开,重新连接后继续发送通知
+		notification := `{"jsonrpc":"2.0","method":"aria2.onDownloadStart","params":[{"gid":"2089b05ecca3d829"}]}`
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(notification)); err != nil || first {
+			return
+		}
+		_, _, _ = conn.ReadMessage()
+	}), ClientUseWebsocket())
+
+	received := make(chan *NotificationEvent, 10)
+	cancelHandler, err := wsClient.OnNotification(func(event *NotificationEvent) {
+		received <- event
+	}, ON_DOWNLOAD_START)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancelHandler()
+	events, cancel, err := wsClient.Notifications(ON_DOWNLOAD_START)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	for _, want := range []NotificationType{ON_DOWNLOAD_START, ON_CONNECTION_LOST, ON_CONNECTION_RESTORED, ON_DOWNLOAD_START} {
+		select {
+		case event := <-received:
+			if event.Type != want || (want == ON_CONNECTION_LOST && event.Err == nil) {
+				t.Fatalf("want %s got %s %v", want, event.Type, event.Err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("handler did not receive %s", want)
+		}
+	}
+
+	// channel 在连接断开后关闭,断开前收到的通知不会丢失
+	count := 0
+	timeout := time.After(5 * time.Second)
+	for closed := false; !closed; {
+		select {
+		case _, ok := <-events:
+			if ok {
+				count++
+			}
+			closed = !ok
+		case <-timeout:
+			t.Fatal("notification channel is not closed after connection lost")
+		}
+	}
+	if count != 1 {
+		t.Errorf("want 1 notification before connection lost got %d", count)
+	}
+}